@@ -16,7 +16,7 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
-	result, err := cli.Run(ctx, os.Stdout)
+	result, err := cli.Run(ctx, os.Args[1:], os.Stdout)
 	if err != nil {
 		if errors.Is(err, huh.ErrUserAborted) || ctx.Err() != nil {
 			os.Exit(130)
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/pipeline"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type ThumbnailHandler struct {
+	Image pipeline.ImagePipeline
+	Video pipeline.Pipeline
+}
+
+func (h ThumbnailHandler) Handle(ctx context.Context, taskItem task.Task) task.Result {
+	var results []task.Result
+	if taskItem.Job.Kind == domain.InputKindImage {
+		results = h.Image.Run(ctx, []job.Job{taskItem.Job}, target.TargetThumbnail)
+	} else {
+		results = h.Video.Run(ctx, []job.Job{taskItem.Job})
+	}
+	if len(results) == 0 {
+		return task.Result{InputPath: taskItem.Job.InputPath, Err: fmt.Errorf("no result")}
+	}
+	return results[0]
+}
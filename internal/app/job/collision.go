@@ -0,0 +1,160 @@
+package job
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type CollisionPolicy string
+
+const (
+	CollisionSuffix CollisionPolicy = "suffix"
+	CollisionMirror CollisionPolicy = "mirror"
+	CollisionFail   CollisionPolicy = "fail"
+)
+
+type Collision struct {
+	OutputPath string
+	Inputs     []string
+}
+
+func ParseCollisionPolicy(value string) (CollisionPolicy, error) {
+	switch CollisionPolicy(value) {
+	case CollisionSuffix, CollisionMirror, CollisionFail:
+		return CollisionPolicy(value), nil
+	default:
+		return "", fmt.Errorf("unknown collision policy: %s", value)
+	}
+}
+
+func ResolveCollisions(jobs []Job, policy CollisionPolicy, outputPaths func(Job) []string) ([]Job, []Collision, error) {
+	resolved := make([]Job, len(jobs))
+	groups := make(map[string][]int)
+	owners := make(map[string]string)
+	order := make([]string, 0)
+	for i, j := range jobs {
+		paths := outputPaths(j)
+		j.OutputPath = paths[0]
+		resolved[i] = j
+		key := collisionKey(j.OutputPath)
+		for _, path := range paths {
+			if owner, ok := owners[collisionKey(path)]; ok {
+				key = owner
+				break
+			}
+		}
+		for _, path := range paths {
+			if _, ok := owners[collisionKey(path)]; !ok {
+				owners[collisionKey(path)] = key
+			}
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	collisions := make([]Collision, 0)
+	for _, key := range order {
+		indexes := groups[key]
+		if len(indexes) < 2 {
+			continue
+		}
+		inputs := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			inputs = append(inputs, resolved[i].InputPath)
+		}
+		collisions = append(collisions, Collision{OutputPath: resolved[indexes[0]].OutputPath, Inputs: inputs})
+	}
+	if len(collisions) == 0 {
+		return resolved, collisions, nil
+	}
+	if policy == CollisionFail {
+		first := collisions[0]
+		return nil, collisions, fmt.Errorf("%d input(s) map to %s (e.g. %s)", len(first.Inputs), first.OutputPath, strings.Join(first.Inputs, ", "))
+	}
+
+	taken := make(map[string]struct{}, len(owners))
+	for key := range owners {
+		taken[key] = struct{}{}
+	}
+	namesFor := func(j Job, path string) []string {
+		j.OutputPath = path
+		return outputPaths(j)
+	}
+	for _, key := range order {
+		indexes := groups[key]
+		if len(indexes) < 2 {
+			continue
+		}
+		if policy == CollisionMirror {
+			for _, i := range indexes {
+				mirrored := mirrorPath(resolved[i])
+				if mirrored == resolved[i].OutputPath {
+					continue
+				}
+				names := namesFor(resolved[i], mirrored)
+				if anyTaken(names, taken) {
+					continue
+				}
+				markTaken(names, taken)
+				resolved[i].OutputPath = mirrored
+			}
+		}
+		seen := make(map[string]struct{})
+		for _, i := range indexes {
+			names := namesFor(resolved[i], resolved[i].OutputPath)
+			if !anyTaken(names, seen) {
+				markTaken(names, seen)
+				continue
+			}
+			resolved[i].OutputPath = suffixPath(resolved[i], taken, namesFor)
+		}
+	}
+	return resolved, collisions, nil
+}
+
+func mirrorPath(j Job) string {
+	if j.Root == "" {
+		return j.OutputPath
+	}
+	rel, err := filepath.Rel(j.Root, filepath.Dir(j.InputPath))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return j.OutputPath
+	}
+	return filepath.Join(filepath.Dir(j.OutputPath), rel, filepath.Base(j.OutputPath))
+}
+
+func suffixPath(j Job, taken map[string]struct{}, namesFor func(Job, string) []string) string {
+	ext := filepath.Ext(j.OutputPath)
+	base := strings.TrimSuffix(j.OutputPath, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		names := namesFor(j, candidate)
+		if anyTaken(names, taken) {
+			continue
+		}
+		markTaken(names, taken)
+		return candidate
+	}
+}
+
+func anyTaken(paths []string, taken map[string]struct{}) bool {
+	for _, path := range paths {
+		if _, ok := taken[collisionKey(path)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func markTaken(paths []string, taken map[string]struct{}) {
+	for _, path := range paths {
+		taken[collisionKey(path)] = struct{}{}
+	}
+}
+
+func collisionKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
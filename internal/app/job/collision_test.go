@@ -0,0 +1,122 @@
+package job
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func stickerPath(j Job) []string {
+	if j.OutputPath != "" {
+		return []string{j.OutputPath}
+	}
+	base := strings.TrimSuffix(filepath.Base(j.InputPath), filepath.Ext(j.InputPath))
+	return []string{filepath.Join(j.OutputDir, base+"_sticker.webm")}
+}
+
+func thumbPaths(j Job) []string {
+	output := j.OutputPath
+	if output == "" {
+		base := strings.TrimSuffix(filepath.Base(j.InputPath), filepath.Ext(j.InputPath))
+		ext := ".png"
+		if filepath.Ext(j.InputPath) == ".webm" {
+			ext = ".webp"
+		}
+		output = filepath.Join(j.OutputDir, base+"_thumb"+ext)
+	}
+	if filepath.Ext(output) == ".png" {
+		return []string{output, strings.TrimSuffix(output, ".png") + ".webp"}
+	}
+	return []string{output}
+}
+
+func TestResolveCollisionsSuffix(t *testing.T) {
+	jobs := []Job{
+		{InputPath: "in/clip.mp4", OutputDir: "out"},
+		{InputPath: "in/clip.gif", OutputDir: "out"},
+		{InputPath: "in/other.mp4", OutputDir: "out"},
+	}
+
+	resolved, collisions, err := ResolveCollisions(jobs, CollisionSuffix, stickerPath)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(collisions) != 1 || len(collisions[0].Inputs) != 2 {
+		t.Fatalf("unexpected collisions: %+v", collisions)
+	}
+	want := []string{
+		filepath.Join("out", "clip_sticker.webm"),
+		filepath.Join("out", "clip_sticker_2.webm"),
+		filepath.Join("out", "other_sticker.webm"),
+	}
+	for i, w := range want {
+		if resolved[i].OutputPath != w {
+			t.Fatalf("job %d: got=%s want=%s", i, resolved[i].OutputPath, w)
+		}
+	}
+}
+
+func TestResolveCollisionsMirror(t *testing.T) {
+	jobs := []Job{
+		{InputPath: filepath.Join("assets", "a", "x.mp4"), OutputDir: "out", Root: "assets"},
+		{InputPath: filepath.Join("assets", "b", "x.mp4"), OutputDir: "out", Root: "assets"},
+		{InputPath: "x.gif", OutputDir: "out"},
+	}
+
+	resolved, _, err := ResolveCollisions(jobs, CollisionMirror, stickerPath)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []string{
+		filepath.Join("out", "a", "x_sticker.webm"),
+		filepath.Join("out", "b", "x_sticker.webm"),
+		filepath.Join("out", "x_sticker.webm"),
+	}
+	for i, w := range want {
+		if resolved[i].OutputPath != w {
+			t.Fatalf("job %d: got=%s want=%s", i, resolved[i].OutputPath, w)
+		}
+	}
+}
+
+func TestResolveCollisionsFail(t *testing.T) {
+	jobs := []Job{
+		{InputPath: "a/x.png", OutputDir: "out"},
+		{InputPath: "b/x.png", OutputDir: "out"},
+	}
+	if _, _, err := ResolveCollisions(jobs, CollisionFail, stickerPath); err == nil {
+		t.Fatal("expected error")
+	}
+
+	unique := []Job{{InputPath: "a/x.png", OutputDir: "out"}}
+	resolved, collisions, err := ResolveCollisions(unique, CollisionFail, stickerPath)
+	if err != nil || len(collisions) != 0 {
+		t.Fatalf("unexpected result: %v %+v", err, collisions)
+	}
+	if resolved[0].OutputPath != filepath.Join("out", "x_sticker.webm") {
+		t.Fatalf("unexpected output: %s", resolved[0].OutputPath)
+	}
+}
+
+func TestResolveCollisionsIncludesFallbackNames(t *testing.T) {
+	jobs := []Job{
+		{InputPath: "in/x.png", OutputDir: "out"},
+		{InputPath: "in/x.webm", OutputDir: "out"},
+	}
+	resolved, collisions, err := ResolveCollisions(jobs, CollisionSuffix, thumbPaths)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(collisions) != 1 || len(collisions[0].Inputs) != 2 {
+		t.Fatalf("expected the fallback name to collide, got %+v", collisions)
+	}
+	want := []string{
+		filepath.Join("out", "x_thumb.png"),
+		filepath.Join("out", "x_thumb_2.webp"),
+	}
+	for i, w := range want {
+		if resolved[i].OutputPath != w {
+			t.Fatalf("job %d: got=%s want=%s", i, resolved[i].OutputPath, w)
+		}
+	}
+}
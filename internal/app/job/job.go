@@ -3,12 +3,20 @@ package job
 import "github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
 
 type Job struct {
-	InputPath string
-	Kind      domain.InputKind
-	OutputDir string
+	InputPath  string
+	Kind       domain.InputKind
+	OutputDir  string
+	OutputPath string
+	Root       string
+	Window     domain.TrimWindow
 }
 
 type Skipped struct {
 	Path   string
 	Reason string
 }
+
+type Warning struct {
+	Path    string
+	Message string
+}
@@ -9,6 +9,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/samber/lo"
+	_ "golang.org/x/image/webp"
+
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
@@ -34,74 +37,56 @@ func (p ImagePipeline) Run(ctx context.Context, jobs []job.Job, targetType targe
 			results = append(results, task.Result{InputPath: job.InputPath, Err: fmt.Errorf("unsupported input kind")})
 			continue
 		}
-		if job.OutputDir != "" {
-			if err := os.MkdirAll(job.OutputDir, 0o755); err != nil {
-				results = append(results, task.Result{InputPath: job.InputPath, Err: err})
-				continue
-			}
-		}
-
-		output := imageOutputPath(job, targetType)
-		opts, err := imageEncodeOptions(targetType)
-		if err != nil {
-			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
-			continue
-		}
-
-		if err := p.Encode.EncodeImage(ctx, job.InputPath, opts, output); err != nil {
+		output := OutputPath(job, targetType)
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
 			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
 			continue
 		}
 
-		info, err := probeImageInfo(output)
+		profile, err := target.ProfileFor(targetType, job.Kind)
 		if err != nil {
 			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
 			continue
 		}
 
-		issues := validateImageOutput(info, targetType)
-		if len(issues) == 0 {
-			results = append(results, task.Result{InputPath: job.InputPath, OutputPath: output})
-			continue
-		}
-		results = append(results, task.Result{InputPath: job.InputPath, Err: fmt.Errorf("validation failed"), Issues: issues})
-	}
-	return results
-}
-
-func imageOutputPath(job job.Job, targetType target.TargetType) string {
-	baseName := strings.TrimSuffix(filepath.Base(job.InputPath), filepath.Ext(job.InputPath))
-	suffix := "_sticker"
-	if targetType == target.TargetEmoji {
-		suffix = "_emoji"
-	}
-	name := baseName + suffix + ".png"
-	if job.OutputDir == "" {
-		return filepath.Join(filepath.Dir(job.InputPath), name)
-	}
-	return filepath.Join(job.OutputDir, name)
-}
+		var lastErr error
+		var lastIssues []domain.ValidationIssue
+		written := make([]string, 0)
+		for _, opts := range domain.BuildImageAttempts(profile) {
+			if err := ctx.Err(); err != nil {
+				results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+				return results
+			}
+			attemptOutput := withExt(output, "."+opts.Profile.Container)
+			if err := p.Encode.EncodeImage(ctx, job.InputPath, opts, attemptOutput); err != nil {
+				lastErr = err
+				continue
+			}
+			if !lo.Contains(written, attemptOutput) {
+				written = append(written, attemptOutput)
+			}
 
-func imageEncodeOptions(targetType target.TargetType) (domain.ImageEncodeOptions, error) {
-	switch targetType {
-	case target.TargetStaticSticker:
-		return domain.ImageEncodeOptions{TargetSide: domain.StaticStickerSide}, nil
-	case target.TargetEmoji:
-		return domain.ImageEncodeOptions{TargetSide: domain.EmojiSide, PadToSquare: true}, nil
-	default:
-		return domain.ImageEncodeOptions{}, fmt.Errorf("unsupported target")
-	}
-}
+			info, err := probeImageInfo(attemptOutput)
+			if err != nil {
+				lastErr = err
+				continue
+			}
 
-func validateImageOutput(info domain.ImageInfo, targetType target.TargetType) []domain.ValidationIssue {
-	switch targetType {
-	case target.TargetStaticSticker:
-		return domain.ValidateStaticStickerImage(info)
-	case target.TargetEmoji:
-		return domain.ValidateEmojiImage(info)
-	default:
-		return []domain.ValidationIssue{{Code: "target", Message: "unsupported target"}}
+			issues := domain.ValidateImage(info, opts.Profile)
+			if len(issues) == 0 {
+				removeStale(written, attemptOutput)
+				results = append(results, task.Result{InputPath: job.InputPath, OutputPath: attemptOutput})
+				lastErr = nil
+				break
+			}
+			lastIssues = issues
+			lastErr = fmt.Errorf("validation failed")
+		}
+		if lastErr != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: lastErr, Issues: lastIssues})
+		}
 	}
+	return results
 }
 
 func probeImageInfo(path string) (domain.ImageInfo, error) {
@@ -116,5 +101,22 @@ func probeImageInfo(path string) (domain.ImageInfo, error) {
 		return domain.ImageInfo{}, err
 	}
 
-	return domain.ImageInfo{Width: config.Width, Height: config.Height, Format: format}, nil
+	stat, err := file.Stat()
+	if err != nil {
+		return domain.ImageInfo{}, err
+	}
+
+	return domain.ImageInfo{Width: config.Width, Height: config.Height, Format: format, SizeBytes: stat.Size()}, nil
+}
+
+func withExt(path string, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
+
+func removeStale(paths []string, keep string) {
+	for _, path := range paths {
+		if path != keep {
+			_ = os.Remove(path)
+		}
+	}
 }
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type fakeImageEncode struct {
+	attempts []domain.ImageEncodeOptions
+}
+
+func (f *fakeImageEncode) EncodeImage(_ context.Context, _ string, opts domain.ImageEncodeOptions, outputPath string) error {
+	f.attempts = append(f.attempts, opts)
+	side := opts.Profile.MaxSide
+	if opts.Profile.Container == "webp" {
+		return os.WriteFile(outputPath, losslessWebP(side, side), 0o644)
+	}
+	return writeNoisePNG(outputPath, side)
+}
+
+func TestImagePipelineThumbnailFallsBackToWebP(t *testing.T) {
+	outDir := t.TempDir()
+	encoder := &fakeImageEncode{}
+	p := ImagePipeline{Encode: encoder}
+
+	jobs := []job.Job{{InputPath: "/tmp/icon.jpg", Kind: domain.InputKindImage, OutputDir: outDir}}
+	results := p.Run(context.Background(), jobs, target.TargetThumbnail)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	result := results[0]
+	if result.Err != nil {
+		t.Fatalf("unexpected err: %v %v", result.Err, result.Issues)
+	}
+	if result.OutputPath != filepath.Join(outDir, "icon_thumb.webp") {
+		t.Fatalf("unexpected output: %s", result.OutputPath)
+	}
+	if len(encoder.attempts) != 2 {
+		t.Fatalf("expected png attempt then webp, got %+v", encoder.attempts)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "icon_thumb.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected oversized png to be removed, got %v", err)
+	}
+}
+
+func writeNoisePNG(path string, side int) error {
+	img := image.NewNRGBA(image.Rect(0, 0, side, side))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: uint8(rng.Intn(256))})
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+func losslessWebP(width int, height int) []byte {
+	data := make([]byte, 0, 26)
+	data = append(data, "RIFF"...)
+	data = binary.LittleEndian.AppendUint32(data, 18)
+	data = append(data, "WEBPVP8L"...)
+	data = binary.LittleEndian.AppendUint32(data, 5)
+	data = append(data, 0x2f)
+	data = binary.LittleEndian.AppendUint32(data, uint32(width-1)|uint32(height-1)<<14)
+	return append(data, 0)
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+var lottiePrecisions = []int{3, 2, 1}
+
+type LottiePipeline struct{}
+
+func (p LottiePipeline) Run(ctx context.Context, jobs []job.Job) []task.Result {
+	results := make([]task.Result, 0, len(jobs))
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			return results
+		}
+		if job.Kind != domain.InputKindLottie {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: fmt.Errorf("unsupported input kind")})
+			continue
+		}
+		profile, err := target.ProfileFor(target.TargetAnimatedSticker, job.Kind)
+		if err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
+
+		data, err := readLottieSource(job.InputPath)
+		if err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
+		doc, err := domain.ParseLottie(data)
+		if err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
+		if issues := domain.ValidateLottie(doc, profile); len(issues) > 0 {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: fmt.Errorf("validation failed"), Issues: issues})
+			continue
+		}
+
+		output := OutputPath(job, target.TargetAnimatedSticker)
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
+
+		var lastErr error
+		var lastIssues []domain.ValidationIssue
+		for _, precision := range lottiePrecisions {
+			minified, err := domain.MinifyLottie(doc, precision)
+			if err != nil {
+				lastErr = err
+				break
+			}
+			size, err := writeTGS(output, minified)
+			if err != nil {
+				lastErr = err
+				break
+			}
+			issues := domain.ValidateTGSSize(size, profile)
+			if len(issues) == 0 {
+				results = append(results, task.Result{InputPath: job.InputPath, OutputPath: output})
+				lastErr = nil
+				break
+			}
+			lastIssues = issues
+			lastErr = fmt.Errorf("validation failed")
+		}
+		if lastErr != nil {
+			_ = os.Remove(output)
+			results = append(results, task.Result{InputPath: job.InputPath, Err: lastErr, Issues: lastIssues})
+		}
+	}
+	return results
+}
+
+func readLottieSource(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte{0x1F, 0x8B}) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func writeTGS(path string, data []byte) (int64, error) {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return 0, err
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return 0, err
+	}
+	return int64(buf.Len()), nil
+}
@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestLottiePipelinePackagesTGS(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "wave.json")
+	source := `{"v":"5.7.4","nm":"wave","fr":60,"ip":0,"op":120,"w":512,"h":512,"layers":[{"ty":4,"nm":"shape","ks":{}}]}`
+	if err := os.WriteFile(input, []byte(source), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	jobs := []job.Job{{InputPath: input, Kind: domain.InputKindLottie, OutputDir: filepath.Join(root, "out")}}
+	results := LottiePipeline{}.Run(context.Background(), jobs)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].OutputPath != filepath.Join(root, "out", "wave_sticker.tgs") {
+		t.Fatalf("unexpected output: %s", results[0].OutputPath)
+	}
+
+	file, err := os.Open(results[0].OutputPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("expected gzip output: %v", err)
+	}
+	var doc map[string]any
+	if err := json.NewDecoder(reader).Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := doc["nm"]; ok {
+		t.Fatalf("expected names to be stripped: %v", doc)
+	}
+}
+
+func TestLottiePipelineReportsIssues(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "slow.json")
+	source := `{"fr":30,"ip":0,"op":90,"w":512,"h":512,"layers":[]}`
+	if err := os.WriteFile(input, []byte(source), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	results := LottiePipeline{}.Run(context.Background(), []job.Job{{InputPath: input, Kind: domain.InputKindLottie}})
+	if len(results) != 1 || results[0].Err == nil || len(results[0].Issues) == 0 {
+		t.Fatalf("expected validation issues: %+v", results)
+	}
+}
+
+func TestLottiePipelineRemovesOversizedOutput(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "big.json")
+	rng := rand.New(rand.NewPCG(1, 2))
+	points := make([]int, 40000)
+	for i := range points {
+		points[i] = rng.IntN(1_000_000)
+	}
+	doc := map[string]any{"fr": 60, "ip": 0, "op": 120, "w": 512, "h": 512, "layers": []any{map[string]any{"ty": 4, "ks": map[string]any{"d": points}}}}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(input, source, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out := filepath.Join(root, "out")
+	results := LottiePipeline{}.Run(context.Background(), []job.Job{{InputPath: input, Kind: domain.InputKindLottie, OutputDir: out}})
+	if len(results) != 1 || results[0].Err == nil || len(results[0].Issues) == 0 || results[0].Issues[0].Code != "size" {
+		t.Fatalf("expected a size failure: %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(out, "big_sticker.tgs")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the oversized output to be removed, got %v", err)
+	}
+}
@@ -2,12 +2,16 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
 )
@@ -20,18 +24,49 @@ type EncodeRunner interface {
 	Encode(ctx context.Context, inputPath string, attempt domain.EncodeAttempt, outputPath string, opts domain.EncodeOptions) error
 }
 
+type SegmentRunner interface {
+	SceneScores(ctx context.Context, path string) ([]domain.FrameScore, error)
+}
+
+type CropDetectRunner interface {
+	DetectCrop(ctx context.Context, path string) (domain.CropRect, error)
+}
+
+type QualityRunner interface {
+	Measure(ctx context.Context, sourcePath string, outputPath string, ref domain.QualityReference, metric domain.QualityMetric) (float64, error)
+}
+
 type Pipeline struct {
-	Probe  ProbeRunner
-	Encode EncodeRunner
+	Probe       ProbeRunner
+	Encode      EncodeRunner
+	Segments    SegmentRunner
+	Crops       CropDetectRunner
+	Meter       QualityRunner
+	Target      target.TargetType
+	Duration    domain.DurationPolicy
+	ChromaKey   domain.ChromaKey
+	Crop        domain.CropOptions
+	Mode        domain.EncodeMode
+	CRF         int
+	Tuning      domain.VPXTuning
+	Quality     domain.QualityMetric
+	AutoSegment bool
+	TimeBudget  time.Duration
 }
 
 func (p Pipeline) Run(ctx context.Context, jobs []job.Job) []task.Result {
 	results := make([]task.Result, 0, len(jobs))
+	targetType := p.targetType()
 	for _, job := range jobs {
 		if err := ctx.Err(); err != nil {
 			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
 			return results
 		}
+		profile, err := target.ProfileFor(targetType, job.Kind)
+		if err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
 		info, err := p.Probe.Probe(ctx, job.InputPath)
 		if err != nil {
 			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
@@ -41,65 +76,337 @@ func (p Pipeline) Run(ctx context.Context, jobs []job.Job) []task.Result {
 			info.InputSizeBytes = stat.Size()
 		}
 
-		attempts, err := domain.BuildAttempts(info, job.Kind)
+		crop, err := p.resolveCrop(ctx, job, info)
+		if err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
+		}
+		window, segment := p.chooseWindow(ctx, job, info, profile)
+		opts := domain.EncodeOptions{
+			TrimSeconds: profile.MaxDurationSeconds,
+			Profile:     profile,
+			Duration:    p.Duration,
+			Window:      window,
+			ChromaKey:   p.ChromaKey,
+			Crop:        crop,
+			Mode:        p.Mode,
+			CRF:         p.CRF,
+			Tuning:      p.Tuning,
+		}
+		attempts, err := domain.BuildAttempts(info, job.Kind, opts)
 		if err != nil {
 			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
 			continue
 		}
 
-		if job.OutputDir != "" {
-			if err := os.MkdirAll(job.OutputDir, 0o755); err != nil {
-				results = append(results, task.Result{InputPath: job.InputPath, Err: err})
-				continue
-			}
+		output := OutputPath(job, targetType)
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Err: err})
+			continue
 		}
-		output := outputPath(job)
-		var lastErr error
-		var lastIssues []domain.ValidationIssue
-		for _, a := range attempts {
-			if err := ctx.Err(); err != nil {
-				results = append(results, task.Result{InputPath: job.InputPath, Err: err})
-				return results
-			}
-			err = p.Encode.Encode(ctx, job.InputPath, a, output, domain.EncodeOptions{TrimSeconds: domain.MaxStickerDurationSeconds})
-			if err != nil {
-				lastErr = err
-				continue
-			}
+		jobCtx, cancel := p.budgetContext(ctx)
+		var history attemptHistory
+		var outcome domain.AttemptOutcome
+		var outInfo domain.MediaInfo
+		var found bool
+		var score domain.QualityScore
+		if p.Quality != domain.QualityOff && p.Meter != nil && !p.ChromaKey.Enabled() {
+			outcome, outInfo, score, found = p.searchBestQuality(ctx, jobCtx, job.InputPath, attempts, output, opts, &history)
+		} else {
+			outcome, outInfo, found = p.search(ctx, jobCtx, job.InputPath, attempts, output, opts, &history)
+		}
+		budgetErr := jobCtx.Err()
+		cancel()
+		if err := ctx.Err(); err != nil {
+			results = append(results, task.Result{InputPath: job.InputPath, Kind: job.Kind, Media: info, Err: err, Attempts: history})
+			return results
+		}
+		result := task.Result{InputPath: job.InputPath, Kind: job.Kind, Media: info, Segment: segment, Attempts: history}
+		if budgetErr != nil && !found {
+			_ = os.Remove(output)
+			result.Err = fmt.Errorf("time budget of %s exhausted", p.TimeBudget)
+			result.Issues = outcome.Issues
+			results = append(results, result)
+			continue
+		}
+		if found {
+			result.OutputPath = output
+			result.Warnings = domain.ValidateAlpha(outcome.Attempt.Alpha, outInfo)
+			result.Quality = score
+			results = append(results, result)
+			continue
+		}
+		result.Err = outcome.Err
+		if result.Err == nil {
+			result.Err = fmt.Errorf("validation failed")
+		}
+		result.Issues = outcome.Issues
+		results = append(results, result)
+	}
+	return results
+}
 
-			stat, statErr := os.Stat(output)
-			if statErr != nil {
-				lastErr = statErr
-				continue
-			}
+func (p Pipeline) budgetContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.TimeBudget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.TimeBudget)
+}
 
-			outInfo, probeErr := p.Probe.Probe(ctx, output)
-			if probeErr != nil {
-				lastErr = probeErr
-				continue
-			}
+func (p Pipeline) search(ctx context.Context, budget context.Context, inputPath string, attempts []domain.EncodeAttempt, output string, opts domain.EncodeOptions, history *attemptHistory) (domain.AttemptOutcome, domain.MediaInfo, bool) {
+	strategy := domain.NewBisectStrategy(attempts, opts.Profile.MaxSizeBytes)
+	var last domain.AttemptOutcome
+	var lastInfo domain.MediaInfo
+	var closest domain.AttemptOutcome
+	encodes := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return domain.AttemptOutcome{Err: err}, domain.MediaInfo{}, false
+		}
+		if budget.Err() != nil {
+			break
+		}
+		a, ok := strategy.Next()
+		if !ok {
+			break
+		}
+		encodes++
+		opts.OnProgress = progressReporter(budget, a, encodes, strategy.Bound())
+		startedAt := time.Now()
+		last, lastInfo = p.encodeAttempt(budget, inputPath, a, output, opts)
+		history.record(last, lastInfo, time.Since(startedAt))
+		if budget.Err() != nil && last.Err != nil {
+			continue
+		}
+		strategy.Observe(last)
+		if closerFailure(last, closest) {
+			closest = last
+		}
+	}
+
+	best, found := strategy.Best()
+	if !found {
+		if budget.Err() != nil && closest.Issues != nil {
+			return closest, domain.MediaInfo{}, false
+		}
+		return last, lastInfo, false
+	}
+	if last.Attempt != best || !last.Passed() {
+		opts.OnProgress = progressReporter(ctx, best, encodes+1, strategy.Bound())
+		startedAt := time.Now()
+		last, lastInfo = p.encodeAttempt(ctx, inputPath, best, output, opts)
+		history.record(last, lastInfo, time.Since(startedAt))
+	}
+	return last, lastInfo, last.Passed()
+}
+
+type attemptHistory []task.AttemptRecord
+
+func (h *attemptHistory) record(outcome domain.AttemptOutcome, info domain.MediaInfo, elapsed time.Duration) {
+	record := task.AttemptRecord{
+		Attempt:   outcome.Attempt,
+		SizeBytes: outcome.SizeBytes,
+		Output:    info,
+		Issues:    outcome.Issues,
+		Err:       outcome.Err,
+		Duration:  elapsed,
+	}
+	var encodeErr *domain.EncodeError
+	if errors.As(outcome.Err, &encodeErr) {
+		record.LogPath = encodeErr.LogPath
+	}
+	*h = append(*h, record)
+}
+
+func progressReporter(ctx context.Context, a domain.EncodeAttempt, index int, total int) func(domain.EncodeProgress) {
+	passes := 1
+	if a.Mode == domain.EncodeTwoPass {
+		passes = 2
+	}
+	return func(progress domain.EncodeProgress) {
+		task.ReportProgress(ctx, task.ProgressEvent{
+			Percent:     progress.Percent(float64(a.DurationSeconds), passes),
+			Attempt:     index,
+			Attempts:    max(total, index),
+			BitrateKbps: a.BitrateKbps,
+			Width:       a.Width,
+			Height:      a.Height,
+			SizeBytes:   progress.SizeBytes,
+		})
+	}
+}
+
+func closerFailure(candidate domain.AttemptOutcome, current domain.AttemptOutcome) bool {
+	if candidate.Err != nil || len(candidate.Issues) == 0 {
+		return false
+	}
+	if current.Issues == nil {
+		return true
+	}
+	if len(candidate.Issues) != len(current.Issues) {
+		return len(candidate.Issues) < len(current.Issues)
+	}
+	return candidate.SizeBytes < current.SizeBytes
+}
+
+func (p Pipeline) searchBestQuality(ctx context.Context, budget context.Context, inputPath string, attempts []domain.EncodeAttempt, output string, opts domain.EncodeOptions, history *attemptHistory) (domain.AttemptOutcome, domain.MediaInfo, domain.QualityScore, bool) {
+	levels := domain.QualityCandidateLevels(attempts, opts.Profile, domain.MaxQualityCandidates)
+	if len(levels) == 0 {
+		outcome, info, found := p.search(ctx, budget, inputPath, attempts, output, opts, history)
+		return outcome, info, domain.QualityScore{}, found
+	}
+	ref := domain.NewQualityReference(levels[0][0], opts)
 
-			issues := domain.ValidateOutput(outInfo, stat.Size())
-			if len(issues) == 0 {
-				results = append(results, task.Result{InputPath: job.InputPath, OutputPath: output})
-				lastErr = nil
+	var last domain.AttemptOutcome
+	var best domain.AttemptOutcome
+	var bestInfo domain.MediaInfo
+	var bestPath string
+	bestValue := math.Inf(-1)
+	found := false
+	for i, level := range levels {
+		candidate := candidatePath(output, i)
+		outcome, info, ok := p.search(ctx, budget, inputPath, level, candidate, opts, history)
+		if last.Issues == nil || closerFailure(outcome, last) {
+			last = outcome
+		}
+		if !ok || ctx.Err() != nil {
+			_ = os.Remove(candidate)
+			if budget.Err() != nil {
 				break
 			}
-			lastIssues = issues
-			lastErr = fmt.Errorf("validation failed")
+			continue
 		}
-		if lastErr != nil {
-			results = append(results, task.Result{InputPath: job.InputPath, Err: lastErr, Issues: lastIssues})
+		value, err := p.Meter.Measure(ctx, inputPath, candidate, ref.ForCandidate(level[0]), p.Quality)
+		if err != nil {
+			value = math.Inf(-1)
 		}
+		if found && value <= bestValue {
+			_ = os.Remove(candidate)
+			continue
+		}
+		if found {
+			_ = os.Remove(bestPath)
+		}
+		best, bestInfo, bestPath, bestValue, found = outcome, info, candidate, value, true
 	}
-	return results
+	if !found {
+		return last, domain.MediaInfo{}, domain.QualityScore{}, false
+	}
+	if err := os.Rename(bestPath, output); err != nil {
+		return domain.AttemptOutcome{Attempt: best.Attempt, Err: err}, domain.MediaInfo{}, domain.QualityScore{}, false
+	}
+	score := domain.QualityScore{}
+	if !math.IsInf(bestValue, -1) {
+		score = domain.QualityScore{Metric: p.Quality, Value: bestValue}
+	}
+	return best, bestInfo, score, true
+}
+
+func candidatePath(output string, index int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s.candidate%d%s", strings.TrimSuffix(output, ext), index, ext)
+}
+
+func (p Pipeline) encodeAttempt(ctx context.Context, inputPath string, a domain.EncodeAttempt, output string, opts domain.EncodeOptions) (domain.AttemptOutcome, domain.MediaInfo) {
+	outcome := domain.AttemptOutcome{Attempt: a}
+	if err := p.Encode.Encode(ctx, inputPath, a, output, opts); err != nil {
+		var tooLarge *domain.AttemptTooLargeError
+		if errors.As(err, &tooLarge) {
+			outcome.SizeBytes = max(tooLarge.SizeBytes, tooLarge.ProjectedBytes)
+			outcome.Issues = []domain.ValidationIssue{{Code: "size", Message: tooLarge.Error()}}
+			return outcome, domain.MediaInfo{}
+		}
+		outcome.Err = err
+		return outcome, domain.MediaInfo{}
+	}
+	stat, err := os.Stat(output)
+	if err != nil {
+		outcome.Err = err
+		return outcome, domain.MediaInfo{}
+	}
+	outcome.SizeBytes = stat.Size()
+	outInfo, err := p.Probe.Probe(ctx, output)
+	if err != nil {
+		outcome.Err = err
+		return outcome, domain.MediaInfo{}
+	}
+	outcome.Issues = domain.ValidateOutput(outInfo, stat.Size(), opts.Profile)
+	return outcome, outInfo
+}
+
+func (p Pipeline) resolveCrop(ctx context.Context, j job.Job, info domain.MediaInfo) (domain.CropRect, error) {
+	detected := domain.CropRect{}
+	if p.Crop.Mode == domain.CropAuto && p.Crops != nil && j.Kind != domain.InputKindImage {
+		rect, err := p.Crops.DetectCrop(ctx, j.InputPath)
+		if err != nil {
+			return domain.CropRect{}, err
+		}
+		detected = rect
+	}
+	return p.Crop.Resolve(info.Width, info.Height, detected)
+}
+
+func (p Pipeline) chooseWindow(ctx context.Context, j job.Job, info domain.MediaInfo, profile domain.Profile) (domain.TrimWindow, domain.TrimWindow) {
+	if !p.AutoSegment || p.Segments == nil || !j.Window.IsZero() || !domain.SupportsTrimWindow(j.Kind) {
+		return j.Window, domain.TrimWindow{}
+	}
+	span, ok := domain.AutoSegmentSpan(info.DurationSeconds, profile.MaxDurationSeconds, p.Duration)
+	if !ok {
+		return j.Window, domain.TrimWindow{}
+	}
+	scores, err := p.Segments.SceneScores(ctx, j.InputPath)
+	if err != nil {
+		return j.Window, domain.TrimWindow{}
+	}
+	segment := domain.BestSegment(scores, info.DurationSeconds, span)
+	return segment, segment
 }
 
-func outputPath(job job.Job) string {
-	baseName := strings.TrimSuffix(filepath.Base(job.InputPath), filepath.Ext(job.InputPath))
-	name := baseName + "_sticker.webm"
-	if job.OutputDir == "" {
-		return filepath.Join(filepath.Dir(job.InputPath), name)
+func (p Pipeline) targetType() target.TargetType {
+	if p.Target == "" {
+		return target.TargetVideoSticker
+	}
+	return p.Target
+}
+
+func OutputPath(j job.Job, targetType target.TargetType) string {
+	if j.OutputPath != "" {
+		return j.OutputPath
+	}
+	baseName := strings.TrimSuffix(filepath.Base(j.InputPath), filepath.Ext(j.InputPath))
+	name := baseName + outputSuffix(targetType, j.Kind)
+	if j.OutputDir == "" {
+		return filepath.Join(filepath.Dir(j.InputPath), name)
+	}
+	return filepath.Join(j.OutputDir, name)
+}
+
+func OutputPaths(j job.Job, targetType target.TargetType) []string {
+	output := OutputPath(j, targetType)
+	paths := []string{output}
+	profile, err := target.ProfileFor(targetType, j.Kind)
+	if err == nil && profile.Fallback != nil && profile.Fallback.Container != profile.Container {
+		paths = append(paths, withExt(output, "."+profile.Fallback.Container))
+	}
+	return paths
+}
+
+func outputSuffix(targetType target.TargetType, kind domain.InputKind) string {
+	switch targetType {
+	case target.TargetStaticSticker:
+		return "_sticker.png"
+	case target.TargetEmoji:
+		return "_emoji.png"
+	case target.TargetVideoEmoji:
+		return "_emoji.webm"
+	case target.TargetThumbnail:
+		if kind == domain.InputKindImage {
+			return "_thumb.png"
+		}
+		return "_thumb.webm"
+	case target.TargetAnimatedSticker:
+		return "_sticker.tgs"
+	default:
+		return "_sticker.webm"
 	}
-	return filepath.Join(job.OutputDir, name)
 }
@@ -5,9 +5,13 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
 )
 
@@ -64,6 +68,94 @@ func TestPipelineUsesOutputDir(t *testing.T) {
 	}
 }
 
+func TestPipelineVideoEmojiOutput(t *testing.T) {
+	encoder := &captureOptions{}
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 640, Height: 360, FPS: 30, DurationSeconds: 2.0}},
+		Encode: encoder,
+		Target: target.TargetVideoEmoji,
+	}
+	_ = p.Run(context.Background(), []job.Job{{InputPath: "/tmp/a.mp4", Kind: domain.InputKindVideo, OutputDir: "/tmp/out"}})
+	if encoder.output != filepath.Join("/tmp/out", "a_emoji.webm") {
+		t.Fatalf("unexpected output: %s", encoder.output)
+	}
+	if encoder.opts.Profile.Name != domain.TelegramVideoEmoji.Name {
+		t.Fatalf("unexpected profile: %+v", encoder.opts.Profile)
+	}
+	if encoder.attempt.Width != 100 || encoder.attempt.Height != 56 {
+		t.Fatalf("unexpected attempt size: %+v", encoder.attempt)
+	}
+}
+
+func TestPipelineScoresCandidatesAgainstOneReference(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &sizedEncode{bytesPerKbps: 140}
+	meter := &recordingMeter{encoder: encoder, refs: make(map[string]domain.QualityReference)}
+	p := Pipeline{
+		Probe:   encodedProbe{source: domain.MediaInfo{Width: 640, Height: 360, FPS: 30, DurationSeconds: 2}, encoder: encoder, padSide: 100},
+		Encode:  encoder,
+		Meter:   meter,
+		Target:  target.TargetVideoEmoji,
+		Quality: domain.QualitySSIM,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(meter.refs) != domain.MaxQualityCandidates {
+		t.Fatalf("expected every candidate measured, got %+v", meter.refs)
+	}
+	sizes := make(map[int]bool)
+	for path, ref := range meter.refs {
+		a := encoder.outputs[path]
+		if ref.Width != 100 || ref.Height != 56 || ref.PadSide != 100 {
+			t.Fatalf("%s: expected the full-scale reference, got %+v", path, ref)
+		}
+		if ref.CandidateWidth != a.Width || ref.CandidateHeight != a.Height {
+			t.Fatalf("%s: candidate size %dx%d not passed with %+v", path, a.Width, a.Height, ref)
+		}
+		sizes[a.Width] = true
+	}
+	if len(sizes) != domain.MaxQualityCandidates {
+		t.Fatalf("expected candidates of different sizes, got %v", sizes)
+	}
+}
+
+func TestPipelineSkipsQualityModeWithChromaKey(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &sizedEncode{bytesPerKbps: 560}
+	meter := &recordingMeter{encoder: encoder, refs: make(map[string]domain.QualityReference)}
+	p := Pipeline{
+		Probe:     encodedProbe{source: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3}, encoder: encoder},
+		Encode:    encoder,
+		Meter:     meter,
+		Quality:   domain.QualitySSIM,
+		ChromaKey: domain.ChromaKey{Color: "0x00FF00", Similarity: 0.2, Blend: 0.1},
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(meter.refs) != 0 || results[0].Quality.Metric != domain.QualityOff {
+		t.Fatalf("expected quality mode off with chroma key, got %+v", results[0].Quality)
+	}
+}
+
+type captureOptions struct {
+	output  string
+	attempt domain.EncodeAttempt
+	opts    domain.EncodeOptions
+}
+
+func (c *captureOptions) Encode(_ context.Context, _ string, attempt domain.EncodeAttempt, outputPath string, opts domain.EncodeOptions) error {
+	if c.output == "" {
+		c.output = outputPath
+		c.attempt = attempt
+		c.opts = opts
+	}
+	return errors.New("encode failed")
+}
+
 type captureFirstAttempt struct {
 	first domain.EncodeAttempt
 	seen  bool
@@ -105,9 +197,311 @@ func TestPipelineUsesInputSizeForFirstAttempt(t *testing.T) {
 }
 
 func expectedBaseBitrateKbps(durationSeconds int) int {
-	bitrate := int(float64(domain.MaxStickerSizeBytes*8) / float64(durationSeconds) / 1000.0)
+	bitrate := int(float64(domain.TelegramVideoSticker.MaxSizeBytes*8) / float64(durationSeconds) / 1000.0)
 	if bitrate < 150 {
 		bitrate = 150
 	}
 	return bitrate
 }
+
+type fakeSegments struct {
+	scores []domain.FrameScore
+}
+
+func (f fakeSegments) SceneScores(_ context.Context, _ string) ([]domain.FrameScore, error) {
+	return f.scores, nil
+}
+
+func TestPipelineAutoSegment(t *testing.T) {
+	encoder := &captureOptions{}
+	p := Pipeline{
+		Probe:       fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 20}},
+		Encode:      encoder,
+		Segments:    fakeSegments{scores: []domain.FrameScore{{TimeSeconds: 12, Score: 0.9}}},
+		AutoSegment: true,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: "/tmp/a.mp4", Kind: domain.InputKindVideo, OutputDir: "/tmp/out"}})
+	want := domain.TrimWindow{StartSeconds: 9.25, EndSeconds: 12.25}
+	if encoder.opts.Window != want {
+		t.Fatalf("unexpected window: %+v", encoder.opts.Window)
+	}
+	if len(results) != 1 || results[0].Segment != want {
+		t.Fatalf("expected segment in result: %+v", results)
+	}
+
+	encoder = &captureOptions{}
+	p.Encode = encoder
+	manual := domain.TrimWindow{StartSeconds: 1, EndSeconds: 4}
+	results = p.Run(context.Background(), []job.Job{{InputPath: "/tmp/a.mp4", Kind: domain.InputKindVideo, OutputDir: "/tmp/out", Window: manual}})
+	if encoder.opts.Window != manual || !results[0].Segment.IsZero() {
+		t.Fatalf("expected manual window to win: %+v %+v", encoder.opts.Window, results[0].Segment)
+	}
+}
+
+type fakeCrops struct {
+	rect domain.CropRect
+}
+
+func (f fakeCrops) DetectCrop(_ context.Context, _ string) (domain.CropRect, error) {
+	return f.rect, nil
+}
+
+func TestPipelineAutoCrop(t *testing.T) {
+	encoder := &captureOptions{}
+	detected := domain.CropRect{Width: 1920, Height: 800, Y: 140}
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 1920, Height: 1080, FPS: 30, DurationSeconds: 2}},
+		Encode: encoder,
+		Crops:  fakeCrops{rect: detected},
+		Crop:   domain.CropOptions{Mode: domain.CropAuto},
+	}
+	_ = p.Run(context.Background(), []job.Job{{InputPath: "/tmp/a.mp4", Kind: domain.InputKindVideo, OutputDir: "/tmp/out"}})
+	if encoder.opts.Crop != detected {
+		t.Fatalf("unexpected crop: %+v", encoder.opts.Crop)
+	}
+	if encoder.attempt.Width != 512 || encoder.attempt.Height != 213 {
+		t.Fatalf("expected post-crop size, got %dx%d", encoder.attempt.Width, encoder.attempt.Height)
+	}
+}
+
+type sizedEncode struct {
+	bytesPerKbps int64
+	bitrates     []int
+	outputs      map[string]domain.EncodeAttempt
+}
+
+func (s *sizedEncode) Encode(_ context.Context, _ string, attempt domain.EncodeAttempt, outputPath string, _ domain.EncodeOptions) error {
+	s.bitrates = append(s.bitrates, attempt.BitrateKbps)
+	if s.outputs == nil {
+		s.outputs = make(map[string]domain.EncodeAttempt)
+	}
+	s.outputs[outputPath] = attempt
+	return os.WriteFile(outputPath, make([]byte, int64(attempt.BitrateKbps)*s.bytesPerKbps), 0o644)
+}
+
+func TestPipelineSearchesBitrateTowardBudget(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &sizedEncode{bytesPerKbps: 560}
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3, CodecName: "vp9", FormatName: "matroska,webm"}},
+		Encode: encoder,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	stat, err := os.Stat(results[0].OutputPath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	limit := domain.TelegramVideoSticker.MaxSizeBytes
+	if stat.Size() > limit || float64(stat.Size()) < float64(limit)*0.85 {
+		t.Fatalf("output is far from budget: %d of %d after %v", stat.Size(), limit, encoder.bitrates)
+	}
+	if len(encoder.bitrates) > 4 {
+		t.Fatalf("too many encodes: %v", encoder.bitrates)
+	}
+	if len(results[0].Attempts) != len(encoder.bitrates) || results[0].Media.Width != 512 || results[0].Kind != domain.InputKindVideo {
+		t.Fatalf("unexpected attempt history: %+v", results[0])
+	}
+	for i, record := range results[0].Attempts {
+		if record.Attempt.BitrateKbps != encoder.bitrates[i] || record.SizeBytes != int64(encoder.bitrates[i])*encoder.bytesPerKbps {
+			t.Fatalf("attempt %d does not match the encode: %+v", i, record)
+		}
+	}
+}
+
+type fakeMeter struct {
+	scores map[string]float64
+}
+
+func (f fakeMeter) Measure(_ context.Context, _ string, outputPath string, _ domain.QualityReference, _ domain.QualityMetric) (float64, error) {
+	for marker, score := range f.scores {
+		if strings.Contains(outputPath, marker) {
+			return score, nil
+		}
+	}
+	return 0, errors.New("unexpected candidate")
+}
+
+type encodedProbe struct {
+	source  domain.MediaInfo
+	encoder *sizedEncode
+	padSide int
+}
+
+func (p encodedProbe) Probe(_ context.Context, path string) (domain.MediaInfo, error) {
+	attempt, ok := p.encoder.outputs[path]
+	if !ok {
+		return p.source, nil
+	}
+	info := domain.MediaInfo{Width: attempt.Width, Height: attempt.Height, FPS: p.source.FPS, DurationSeconds: float64(attempt.DurationSeconds), CodecName: "vp9", FormatName: "matroska,webm"}
+	if attempt.FPS > 0 {
+		info.FPS = float64(attempt.FPS)
+	}
+	if p.padSide > 0 {
+		info.Width, info.Height = p.padSide, p.padSide
+	}
+	return info, nil
+}
+
+type recordingMeter struct {
+	encoder *sizedEncode
+	refs    map[string]domain.QualityReference
+}
+
+func (m *recordingMeter) Measure(_ context.Context, _ string, outputPath string, ref domain.QualityReference, _ domain.QualityMetric) (float64, error) {
+	m.refs[outputPath] = ref
+	return float64(m.encoder.outputs[outputPath].Width), nil
+}
+
+func TestPipelineKeepsBestQualityCandidate(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &sizedEncode{bytesPerKbps: 560}
+	p := Pipeline{
+		Probe:   encodedProbe{source: domain.MediaInfo{Width: 1280, Height: 720, FPS: 30, DurationSeconds: 3}, encoder: encoder},
+		Encode:  encoder,
+		Meter:   fakeMeter{scores: map[string]float64{".candidate0": 0.91, ".candidate1": 0.95, ".candidate2": 0.93}},
+		Quality: domain.QualitySSIM,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Quality != (domain.QualityScore{Metric: domain.QualitySSIM, Value: 0.95}) {
+		t.Fatalf("unexpected quality: %+v", results[0].Quality)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a_sticker.webm" {
+		t.Fatalf("expected only the chosen output, got %v", entries)
+	}
+}
+
+type abortingEncode struct {
+	bytesPerKbps int64
+	limit        int64
+	calls        int
+}
+
+func (a *abortingEncode) Encode(_ context.Context, _ string, attempt domain.EncodeAttempt, outputPath string, _ domain.EncodeOptions) error {
+	a.calls++
+	size := int64(attempt.BitrateKbps) * a.bytesPerKbps
+	if size > a.limit {
+		return &domain.AttemptTooLargeError{SizeBytes: a.limit + 1, ProjectedBytes: size, LimitBytes: a.limit}
+	}
+	return os.WriteFile(outputPath, make([]byte, size), 0o644)
+}
+
+func TestPipelineTreatsAbortedAttemptAsOversized(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &abortingEncode{bytesPerKbps: 1200, limit: domain.TelegramVideoSticker.MaxSizeBytes}
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3, CodecName: "vp9", FormatName: "matroska,webm"}},
+		Encode: encoder,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if encoder.calls > 5 {
+		t.Fatalf("too many encodes: %d", encoder.calls)
+	}
+}
+
+type slowOversizedEncode struct {
+	calls int
+}
+
+func (s *slowOversizedEncode) Encode(ctx context.Context, _ string, attempt domain.EncodeAttempt, outputPath string, _ domain.EncodeOptions) error {
+	s.calls++
+	if err := os.WriteFile(outputPath, []byte("partial"), 0o644); err != nil {
+		return err
+	}
+	if s.calls > 2 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	limit := domain.TelegramVideoSticker.MaxSizeBytes
+	return &domain.AttemptTooLargeError{SizeBytes: limit + 1, ProjectedBytes: limit * int64(4-s.calls), LimitBytes: limit}
+}
+
+func TestPipelineStopsAtTimeBudget(t *testing.T) {
+	dir := t.TempDir()
+	encoder := &slowOversizedEncode{}
+	p := Pipeline{
+		Probe:      fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3, CodecName: "vp9", FormatName: "matroska,webm"}},
+		Encode:     encoder,
+		TimeBudget: 20 * time.Millisecond,
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "time budget") {
+		t.Fatalf("expected budget error, got %+v", results)
+	}
+	if len(results[0].Issues) != 1 || results[0].Issues[0].Code != "size" {
+		t.Fatalf("expected closest failing issues, got %+v", results[0].Issues)
+	}
+	if encoder.calls != 3 {
+		t.Fatalf("expected search to stop after the budget, got %d encodes", encoder.calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a_sticker.webm")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the partial output to be removed, got %v", err)
+	}
+}
+
+type progressEncode struct{}
+
+func (progressEncode) Encode(_ context.Context, _ string, attempt domain.EncodeAttempt, outputPath string, opts domain.EncodeOptions) error {
+	opts.OnProgress(domain.EncodeProgress{Pass: 2, OutSeconds: float64(attempt.DurationSeconds) / 2, SizeBytes: 1024})
+	return os.WriteFile(outputPath, []byte("ok"), 0o644)
+}
+
+func TestPipelineReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	var events []task.ProgressEvent
+	ctx := task.WithProgress(context.Background(), func(event task.ProgressEvent) {
+		events = append(events, event)
+	})
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3, CodecName: "vp9", FormatName: "matroska,webm"}},
+		Encode: progressEncode{},
+	}
+	results := p.Run(ctx, []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected one progress event, got %+v", events)
+	}
+	event := events[0]
+	if event.Percent != 75 || event.Attempt != 1 || event.Attempts != domain.MaxBisectIterations || event.Width != 512 || event.BitrateKbps == 0 || event.SizeBytes != 1024 {
+		t.Fatalf("unexpected progress event: %+v", event)
+	}
+}
+
+type failingEncode struct {
+	err error
+}
+
+func (f failingEncode) Encode(_ context.Context, _ string, _ domain.EncodeAttempt, _ string, _ domain.EncodeOptions) error {
+	return f.err
+}
+
+func TestPipelineRecordsEncodeErrorLog(t *testing.T) {
+	dir := t.TempDir()
+	p := Pipeline{
+		Probe:  fakeProbe{info: domain.MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3}},
+		Encode: failingEncode{err: &domain.EncodeError{Err: errors.New("ffmpeg failed"), LogPath: "a.log"}},
+	}
+	results := p.Run(context.Background(), []job.Job{{InputPath: filepath.Join(dir, "a.mp4"), Kind: domain.InputKindVideo, OutputDir: dir}})
+	if len(results) != 1 || results[0].Err == nil || len(results[0].Attempts) == 0 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, record := range results[0].Attempts {
+		if record.LogPath != "a.log" || record.Err == nil {
+			t.Fatalf("expected the ffmpeg log on every attempt, got %+v", record)
+		}
+	}
+}
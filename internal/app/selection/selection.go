@@ -1,7 +1,11 @@
 package selection
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
@@ -20,10 +24,16 @@ type ExpandResult struct {
 	TotalFiles int
 	OutputDirs []string
 	Skipped    []job.Skipped
+	Warnings   []job.Warning
 }
 
 type SelectionExpander struct {
-	ListFiles func(root string) ([]string, error)
+	ScanFiles   func(root string, opts infra.ScanOptions) (infra.ScanResult, error)
+	ReadHeader  func(path string, size int) ([]byte, error)
+	ReadSidecar func(path string) (domain.TrimWindow, bool, error)
+	Scan        infra.ScanOptions
+	MirrorTree  bool
+	Window      domain.TrimWindow
 }
 
 func (e SelectionExpander) Expand(selections []SelectionItem, outputDir string) (ExpandResult, error) {
@@ -31,9 +41,9 @@ func (e SelectionExpander) Expand(selections []SelectionItem, outputDir string)
 	if outputDir == "" {
 		outputDir = "./output"
 	}
-	listFiles := e.ListFiles
-	if listFiles == nil {
-		listFiles = infra.ListFiles
+	scanFiles := e.ScanFiles
+	if scanFiles == nil {
+		scanFiles = infra.ScanFiles
 	}
 
 	jobs := make([]job.Job, 0)
@@ -51,40 +61,59 @@ func (e SelectionExpander) Expand(selections []SelectionItem, outputDir string)
 	}
 
 	for _, s := range files {
-		kind, err := domain.DetectInputKind(s.Path)
+		key := pathKey(s.Path)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		kind, err := e.detect(&result, s.Path)
 		if err != nil {
 			result.Skipped = append(result.Skipped, job.Skipped{Path: s.Path, Reason: err.Error()})
 			continue
 		}
-		if _, ok := seen[s.Path]; ok {
+		window, err := e.window(s.Path, kind)
+		if err != nil {
+			result.Skipped = append(result.Skipped, job.Skipped{Path: s.Path, Reason: err.Error()})
 			continue
 		}
-		seen[s.Path] = struct{}{}
-		jobs = append(jobs, job.Job{InputPath: s.Path, Kind: kind, OutputDir: outputDir})
+		jobs = append(jobs, job.Job{InputPath: s.Path, Kind: kind, OutputDir: outputDir, Window: window})
 		result.FileCount++
 		result.TotalFiles++
 		outputSet[outputDir] = struct{}{}
 	}
 
 	for _, s := range dirs {
-		filesInDir, err := listFiles(s.Path)
+		scanned, err := scanFiles(s.Path, e.Scan)
 		if err != nil {
 			return ExpandResult{}, err
 		}
 		result.DirCount++
-		for _, path := range filesInDir {
-			kind, err := domain.DetectInputKind(path)
+		for _, skipped := range scanned.Skipped {
+			result.Skipped = append(result.Skipped, job.Skipped{Path: skipped.Path, Reason: skipped.Reason})
+		}
+		for _, path := range scanned.Files {
+			key := pathKey(path)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			kind, err := e.detect(&result, path)
 			if err != nil {
 				result.Skipped = append(result.Skipped, job.Skipped{Path: path, Reason: err.Error()})
 				continue
 			}
-			if _, ok := seen[path]; ok {
+			window, err := e.window(path, kind)
+			if err != nil {
+				result.Skipped = append(result.Skipped, job.Skipped{Path: path, Reason: err.Error()})
 				continue
 			}
-			seen[path] = struct{}{}
-			jobs = append(jobs, job.Job{InputPath: path, Kind: kind, OutputDir: outputDir})
+			jobOutputDir := outputDir
+			if e.MirrorTree {
+				jobOutputDir = mirroredOutputDir(outputDir, s.Path, path)
+			}
+			jobs = append(jobs, job.Job{InputPath: path, Kind: kind, OutputDir: jobOutputDir, Root: s.Path, Window: window})
 			result.TotalFiles++
-			outputSet[outputDir] = struct{}{}
+			outputSet[jobOutputDir] = struct{}{}
 		}
 	}
 
@@ -93,6 +122,94 @@ func (e SelectionExpander) Expand(selections []SelectionItem, outputDir string)
 	return result, nil
 }
 
+func (e SelectionExpander) detect(result *ExpandResult, path string) (domain.InputKind, error) {
+	readHeader := e.ReadHeader
+	if readHeader == nil {
+		readHeader = infra.ReadFileHeader
+	}
+	header, err := readHeader(path, domain.SniffBytesFor(path))
+	if err != nil {
+		header = nil
+	}
+	detection, err := domain.DetectInput(path, header)
+	if err != nil {
+		return "", err
+	}
+	if detection.Warning != "" {
+		result.Warnings = append(result.Warnings, job.Warning{Path: path, Message: detection.Warning})
+	}
+	return detection.Kind, nil
+}
+
+func (e SelectionExpander) window(path string, kind domain.InputKind) (domain.TrimWindow, error) {
+	if !domain.SupportsTrimWindow(kind) {
+		return domain.TrimWindow{}, nil
+	}
+	readSidecar := e.ReadSidecar
+	if readSidecar == nil {
+		readSidecar = infra.ReadSidecar
+	}
+	window, ok, err := readSidecar(path)
+	if err != nil {
+		return domain.TrimWindow{}, err
+	}
+	if ok {
+		return window, nil
+	}
+	return e.Window, nil
+}
+
+func mirroredOutputDir(outputDir string, root string, path string) string {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return outputDir
+	}
+	return filepath.Join(outputDir, rel)
+}
+
+func ResolvePaths(paths []string) ([]SelectionItem, error) {
+	items := make([]SelectionItem, 0, len(paths))
+	seen := make(map[string]struct{})
+	for _, p := range paths {
+		matches := []string{p}
+		if _, err := os.Stat(p); err != nil && hasGlobMeta(p) {
+			globbed, err := filepath.Glob(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %w", p, err)
+			}
+			if len(globbed) == 0 {
+				return nil, fmt.Errorf("no matches for %s", p)
+			}
+			matches = globbed
+		}
+		for _, m := range matches {
+			key := pathKey(m)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			stat, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			seen[key] = struct{}{}
+			items = append(items, SelectionItem{Path: m, IsDir: stat.IsDir()})
+		}
+	}
+	return items, nil
+}
+
+func pathKey(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
 func sortedKeys(set map[string]struct{}) []string {
 	keys := make([]string, 0, len(set))
 	for k := range set {
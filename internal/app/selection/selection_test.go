@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
 )
 
 func TestExpandSelections(t *testing.T) {
@@ -46,3 +49,192 @@ func TestExpandSelections(t *testing.T) {
 		t.Fatalf("unexpected output dirs: %+v", result.Jobs)
 	}
 }
+
+func TestExpandSelectionsMirrorTree(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"cat", "dog"} {
+		if err := os.MkdirAll(filepath.Join(root, "characters", dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "characters", dir, "wave.mp4"), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "characters", "logo.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	output := filepath.Join(root, "output")
+	expander := SelectionExpander{MirrorTree: true}
+	result, err := expander.Expand([]SelectionItem{{Path: filepath.Join(root, "characters"), IsDir: true}}, output)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := map[string]string{
+		filepath.Join(root, "characters", "cat", "wave.mp4"): filepath.Join(output, "cat"),
+		filepath.Join(root, "characters", "dog", "wave.mp4"): filepath.Join(output, "dog"),
+		filepath.Join(root, "characters", "logo.mp4"):        output,
+	}
+	for _, j := range result.Jobs {
+		if want[j.InputPath] != j.OutputDir {
+			t.Fatalf("%s: output dir=%s want=%s", j.InputPath, j.OutputDir, want[j.InputPath])
+		}
+	}
+	if len(result.OutputDirs) != 3 {
+		t.Fatalf("unexpected output dirs: %v", result.OutputDirs)
+	}
+}
+
+func TestExpandSelectionsReportsScanSkips(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.gif", "b.gif", ".c.gif"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	expander := SelectionExpander{Scan: infra.ScanOptions{Exclude: []string{"b.*"}}}
+	result, err := expander.Expand([]SelectionItem{{Path: root, IsDir: true}}, filepath.Join(root, "output"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(result.Jobs) != 1 || result.Jobs[0].InputPath != filepath.Join(root, "a.gif") {
+		t.Fatalf("unexpected jobs: %+v", result.Jobs)
+	}
+	if len(result.Skipped) != 2 {
+		t.Fatalf("unexpected skipped: %+v", result.Skipped)
+	}
+}
+
+func TestExpandSelectionsDetectsByContent(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "clip.mp4")
+	if err := os.WriteFile(path, []byte("GIF89a\x01\x00\x01\x00"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	result, err := SelectionExpander{}.Expand([]SelectionItem{{Path: path}}, filepath.Join(root, "output"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(result.Jobs) != 1 || result.Jobs[0].Kind != domain.InputKindGIF {
+		t.Fatalf("unexpected jobs: %+v", result.Jobs)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Path != path {
+		t.Fatalf("unexpected warnings: %+v", result.Warnings)
+	}
+}
+
+func TestExpandSelectionsTrimWindow(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	sidecars := map[string]string{
+		"b.mp4": `{"start": "0:02", "end": 5}`,
+		"c.mp4": `{"start": 5, "end": 2}`,
+	}
+	for name, content := range sidecars {
+		if err := os.WriteFile(infra.SidecarPath(filepath.Join(root, name)), []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	expander := SelectionExpander{Window: domain.TrimWindow{StartSeconds: 1}}
+	result, err := expander.Expand([]SelectionItem{{Path: root, IsDir: true}}, filepath.Join(root, "output"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := map[string]domain.TrimWindow{
+		filepath.Join(root, "a.mp4"): {StartSeconds: 1},
+		filepath.Join(root, "b.mp4"): {StartSeconds: 2, EndSeconds: 5},
+	}
+	if len(result.Jobs) != len(want) {
+		t.Fatalf("unexpected jobs: %+v", result.Jobs)
+	}
+	for _, j := range result.Jobs {
+		if j.Window != want[j.InputPath] {
+			t.Fatalf("%s: window=%+v want=%+v", j.InputPath, j.Window, want[j.InputPath])
+		}
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != filepath.Join(root, "c.mp4") {
+		t.Fatalf("unexpected skipped: %+v", result.Skipped)
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "cats"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"a.png", "b.png", "c.mp4"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	items, err := ResolvePaths([]string{
+		filepath.Join(root, "*.png"),
+		filepath.Join(root, "a.png"),
+		filepath.Join(root, "cats"),
+		filepath.Join(root, "c.mp4"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("expected 4 items, got %+v", items)
+	}
+	if items[2].Path != filepath.Join(root, "cats") || !items[2].IsDir {
+		t.Fatalf("expected directory item, got %+v", items[2])
+	}
+	if items[3].IsDir {
+		t.Fatalf("expected file item, got %+v", items[3])
+	}
+
+	if _, err := ResolvePaths([]string{filepath.Join(root, "*.gif")}); err == nil {
+		t.Fatal("expected error for unmatched glob")
+	}
+	if _, err := ResolvePaths([]string{filepath.Join(root, "missing.mp4")}); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
+
+func TestResolvePathsDedupesEquivalentPaths(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "x.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	items, err := ResolvePaths([]string{
+		filepath.Join(root, "x.mp4"),
+		root + string(filepath.Separator) + "." + string(filepath.Separator) + "x.mp4",
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected one item, got %+v", items)
+	}
+}
+
+func TestExpandSelectionsDedupesFileInsideSelectedDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "x.mp4"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Chdir(root)
+	selections := []SelectionItem{
+		{Path: "./x.mp4"},
+		{Path: ".", IsDir: true},
+	}
+	result, err := SelectionExpander{}.Expand(selections, "out")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(result.Jobs) != 1 {
+		t.Fatalf("expected one job, got %+v", result.Jobs)
+	}
+}
@@ -1,6 +1,8 @@
 package target
 
 import (
+	"fmt"
+
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
 )
@@ -8,16 +10,21 @@ import (
 type TargetType string
 
 const (
-	TargetVideoSticker  TargetType = "video_sticker"
-	TargetStaticSticker TargetType = "static_sticker"
-	TargetEmoji         TargetType = "emoji"
+	TargetVideoSticker    TargetType = "video_sticker"
+	TargetStaticSticker   TargetType = "static_sticker"
+	TargetEmoji           TargetType = "emoji"
+	TargetVideoEmoji      TargetType = "video_emoji"
+	TargetThumbnail       TargetType = "thumbnail"
+	TargetAnimatedSticker TargetType = "animated_sticker"
 )
 
 type InputSummary struct {
-	Total int
-	Image int
-	GIF   int
-	Video int
+	Total    int
+	Image    int
+	GIF      int
+	Animated int
+	Video    int
+	Lottie   int
 }
 
 type TargetStatus int
@@ -41,11 +48,48 @@ func TargetLabel(target TargetType) string {
 		return "Static Sticker"
 	case TargetEmoji:
 		return "Emoji"
+	case TargetVideoEmoji:
+		return "Video Emoji"
+	case TargetThumbnail:
+		return "Set Thumbnail"
+	case TargetAnimatedSticker:
+		return "Animated Sticker (TGS)"
 	default:
 		return "Unknown"
 	}
 }
 
+func ParseTargetType(value string) (TargetType, error) {
+	switch TargetType(value) {
+	case TargetVideoSticker, TargetStaticSticker, TargetEmoji, TargetVideoEmoji, TargetThumbnail, TargetAnimatedSticker:
+		return TargetType(value), nil
+	default:
+		return "", fmt.Errorf("unknown target: %s", value)
+	}
+}
+
+func ProfileFor(target TargetType, kind domain.InputKind) (domain.Profile, error) {
+	switch target {
+	case TargetVideoSticker:
+		return domain.TelegramVideoSticker, nil
+	case TargetStaticSticker:
+		return domain.TelegramStaticSticker, nil
+	case TargetEmoji:
+		return domain.TelegramEmoji, nil
+	case TargetVideoEmoji:
+		return domain.TelegramVideoEmoji, nil
+	case TargetThumbnail:
+		if kind == domain.InputKindImage {
+			return domain.TelegramThumbnail, nil
+		}
+		return domain.TelegramVideoThumbnail, nil
+	case TargetAnimatedSticker:
+		return domain.TelegramAnimatedSticker, nil
+	default:
+		return domain.Profile{}, fmt.Errorf("unsupported target")
+	}
+}
+
 func SummarizeJobs(jobs []job.Job) InputSummary {
 	summary := InputSummary{}
 	for _, job := range jobs {
@@ -55,6 +99,10 @@ func SummarizeJobs(jobs []job.Job) InputSummary {
 			summary.Image++
 		case domain.InputKindGIF:
 			summary.GIF++
+		case domain.InputKindAnimatedImage:
+			summary.Animated++
+		case domain.InputKindLottie:
+			summary.Lottie++
 		case domain.InputKindVideo:
 			summary.Video++
 		}
@@ -88,10 +136,14 @@ func FilterJobsForTarget(jobs []job.Job, target TargetType) []job.Job {
 
 func allowedCount(summary InputSummary, target TargetType) int {
 	switch target {
-	case TargetVideoSticker:
-		return summary.Video + summary.GIF
+	case TargetVideoSticker, TargetVideoEmoji:
+		return summary.Video + summary.GIF + summary.Animated
 	case TargetStaticSticker, TargetEmoji:
 		return summary.Image
+	case TargetThumbnail:
+		return summary.Image + summary.Video + summary.GIF + summary.Animated
+	case TargetAnimatedSticker:
+		return summary.Lottie
 	default:
 		return 0
 	}
@@ -99,10 +151,14 @@ func allowedCount(summary InputSummary, target TargetType) int {
 
 func isAllowedKind(kind domain.InputKind, target TargetType) bool {
 	switch target {
-	case TargetVideoSticker:
-		return kind == domain.InputKindVideo || kind == domain.InputKindGIF
+	case TargetVideoSticker, TargetVideoEmoji:
+		return kind == domain.InputKindVideo || domain.IsAnimatedKind(kind)
 	case TargetStaticSticker, TargetEmoji:
 		return kind == domain.InputKindImage
+	case TargetThumbnail:
+		return kind == domain.InputKindImage || kind == domain.InputKindVideo || domain.IsAnimatedKind(kind)
+	case TargetAnimatedSticker:
+		return kind == domain.InputKindLottie
 	default:
 		return false
 	}
@@ -110,10 +166,14 @@ func isAllowedKind(kind domain.InputKind, target TargetType) bool {
 
 func blockedMessage(target TargetType) string {
 	switch target {
-	case TargetVideoSticker:
+	case TargetVideoSticker, TargetVideoEmoji:
 		return "Must select videos or GIFs for this target"
 	case TargetStaticSticker, TargetEmoji:
 		return "Must select images for this target"
+	case TargetThumbnail:
+		return "Must select an image, video or GIF for this target"
+	case TargetAnimatedSticker:
+		return "Must select Lottie JSON or TGS files for this target"
 	default:
 		return "No valid inputs"
 	}
@@ -121,10 +181,12 @@ func blockedMessage(target TargetType) string {
 
 func warningMessage(target TargetType) string {
 	switch target {
-	case TargetVideoSticker:
+	case TargetVideoSticker, TargetVideoEmoji:
 		return "Only videos or GIFs will be processed"
 	case TargetStaticSticker, TargetEmoji:
 		return "Only images will be processed"
+	case TargetAnimatedSticker:
+		return "Only Lottie JSON or TGS files will be processed"
 	default:
 		return "Some inputs will be skipped"
 	}
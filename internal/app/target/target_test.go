@@ -13,10 +13,11 @@ func TestSummarizeJobs(t *testing.T) {
 		{InputPath: "b.gif", Kind: domain.InputKindGIF},
 		{InputPath: "c.mp4", Kind: domain.InputKindVideo},
 		{InputPath: "d.webp", Kind: domain.InputKindImage},
+		{InputPath: "e.png", Kind: domain.InputKindAnimatedImage},
 	}
 
 	summary := SummarizeJobs(jobs)
-	if summary.Total != 4 {
+	if summary.Total != 5 {
 		t.Fatalf("total=%d", summary.Total)
 	}
 	if summary.Image != 2 {
@@ -28,6 +29,9 @@ func TestSummarizeJobs(t *testing.T) {
 	if summary.Video != 1 {
 		t.Fatalf("video=%d", summary.Video)
 	}
+	if summary.Animated != 1 {
+		t.Fatalf("animated=%d", summary.Animated)
+	}
 }
 
 func TestEvaluateTargetStaticSticker(t *testing.T) {
@@ -120,15 +124,71 @@ func TestFilterJobsForTarget(t *testing.T) {
 		{InputPath: "a.png", Kind: domain.InputKindImage},
 		{InputPath: "b.gif", Kind: domain.InputKindGIF},
 		{InputPath: "c.mp4", Kind: domain.InputKindVideo},
+		{InputPath: "d.webp", Kind: domain.InputKindAnimatedImage},
 	}
 
 	filteredVideo := FilterJobsForTarget(jobs, TargetVideoSticker)
-	if len(filteredVideo) != 2 {
+	if len(filteredVideo) != 3 {
 		t.Fatalf("video len=%d", len(filteredVideo))
 	}
 
+	filteredVideoEmoji := FilterJobsForTarget(jobs, TargetVideoEmoji)
+	if len(filteredVideoEmoji) != 3 {
+		t.Fatalf("video emoji len=%d", len(filteredVideoEmoji))
+	}
+
+	filteredThumbnail := FilterJobsForTarget(jobs, TargetThumbnail)
+	if len(filteredThumbnail) != 4 {
+		t.Fatalf("thumbnail len=%d", len(filteredThumbnail))
+	}
+
+	if len(FilterJobsForTarget(jobs, TargetAnimatedSticker)) != 0 {
+		t.Fatal("expected no lottie jobs")
+	}
+	lottieJobs := FilterJobsForTarget(append(jobs, job.Job{InputPath: "e.json", Kind: domain.InputKindLottie}), TargetAnimatedSticker)
+	if len(lottieJobs) != 1 {
+		t.Fatalf("animated sticker len=%d", len(lottieJobs))
+	}
+
 	filteredStatic := FilterJobsForTarget(jobs, TargetStaticSticker)
 	if len(filteredStatic) != 1 {
 		t.Fatalf("static len=%d", len(filteredStatic))
 	}
 }
+
+func TestParseTargetType(t *testing.T) {
+	got, err := ParseTargetType("static_sticker")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got != TargetStaticSticker {
+		t.Fatalf("target=%s", got)
+	}
+
+	if _, err := ParseTargetType("sticker"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestProfileFor(t *testing.T) {
+	profile, err := ProfileFor(TargetEmoji, domain.InputKindImage)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if profile.MaxSide != 100 || !profile.Square {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	thumbImage, err := ProfileFor(TargetThumbnail, domain.InputKindImage)
+	if err != nil || thumbImage.Container != "png" {
+		t.Fatalf("unexpected thumbnail profile: %+v %v", thumbImage, err)
+	}
+	thumbVideo, err := ProfileFor(TargetThumbnail, domain.InputKindGIF)
+	if err != nil || thumbVideo.Container != "webm" {
+		t.Fatalf("unexpected thumbnail profile: %+v %v", thumbVideo, err)
+	}
+
+	if _, err := ProfileFor(TargetType("unknown"), domain.InputKindImage); err == nil {
+		t.Fatal("expected error")
+	}
+}
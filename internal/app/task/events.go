@@ -1,7 +1,27 @@
 package task
 
+import "context"
+
 type ProgressEvent struct {
-	Total   int
-	Done    int
-	Current string
+	Percent     float64
+	Attempt     int
+	Attempts    int
+	BitrateKbps int
+	Width       int
+	Height      int
+	SizeBytes   int64
+}
+
+type ProgressFunc func(ProgressEvent)
+
+type progressKey struct{}
+
+func WithProgress(ctx context.Context, report ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+func ReportProgress(ctx context.Context, event ProgressEvent) {
+	if report, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && report != nil {
+		report(event)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
 )
@@ -12,9 +13,12 @@ import (
 type TaskType string
 
 const (
-	TaskTypeVideoSticker  TaskType = "video_sticker"
-	TaskTypeStaticSticker TaskType = "static_sticker"
-	TaskTypeEmoji         TaskType = "emoji"
+	TaskTypeVideoSticker    TaskType = "video_sticker"
+	TaskTypeStaticSticker   TaskType = "static_sticker"
+	TaskTypeEmoji           TaskType = "emoji"
+	TaskTypeVideoEmoji      TaskType = "video_emoji"
+	TaskTypeThumbnail       TaskType = "thumbnail"
+	TaskTypeAnimatedSticker TaskType = "animated_sticker"
 )
 
 type Task struct {
@@ -33,12 +37,15 @@ type TaskEventType int
 const (
 	TaskStarted TaskEventType = iota
 	TaskFinished
+	TaskProgress
 )
 
 type TaskEvent struct {
-	Type   TaskEventType
-	Task   Task
-	Result Result
+	Type     TaskEventType
+	Worker   int
+	Task     Task
+	Result   Result
+	Progress ProgressEvent
 }
 
 type Executor struct {
@@ -65,7 +72,7 @@ func (e Executor) Run(ctx context.Context, tasks []Task, events chan<- TaskEvent
 		handlerLookup = map[TaskType]TaskHandler{}
 	}
 
-	worker := func() {
+	worker := func(id int) {
 		defer wg.Done()
 		for {
 			select {
@@ -78,17 +85,20 @@ func (e Executor) Run(ctx context.Context, tasks []Task, events chan<- TaskEvent
 				if ctx.Err() != nil {
 					return
 				}
-				events <- TaskEvent{Type: TaskStarted, Task: task}
+				events <- TaskEvent{Type: TaskStarted, Worker: id, Task: task}
 				handler := handlerLookup[task.Type]
-				result := runTask(ctx, task, handler)
-				events <- TaskEvent{Type: TaskFinished, Task: task, Result: result}
+				taskCtx := WithProgress(ctx, func(progress ProgressEvent) {
+					events <- TaskEvent{Type: TaskProgress, Worker: id, Task: task, Progress: progress}
+				})
+				result := runTask(taskCtx, task, handler)
+				events <- TaskEvent{Type: TaskFinished, Worker: id, Task: task, Result: result}
 			}
 		}
 	}
 
 	wg.Add(concurrency)
-	for range concurrency {
-		go worker()
+	for id := range concurrency {
+		go worker(id)
 	}
 
 	for _, task := range tasks {
@@ -130,10 +140,17 @@ func runTask(ctx context.Context, task Task, handler TaskHandler) Result {
 		}
 		return Result{
 			InputPath: inputPath,
+			Kind:      task.Job.Kind,
 			Err:       fmt.Errorf("no handler for task type %s", task.Type),
 		}
 	}
+	startedAt := time.Now()
 	result := handler.Handle(ctx, task)
+	result.StartedAt = startedAt
+	result.FinishedAt = time.Now()
+	if result.Kind == "" {
+		result.Kind = task.Job.Kind
+	}
 	if result.InputPath == "" {
 		if task.Label != "" {
 			result.InputPath = task.Label
@@ -1,10 +1,32 @@
 package task
 
-import "github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+import (
+	"time"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
 
 type Result struct {
 	InputPath  string
 	OutputPath string
+	Kind       domain.InputKind
+	Media      domain.MediaInfo
 	Err        error
 	Issues     []domain.ValidationIssue
+	Warnings   []domain.ValidationIssue
+	Segment    domain.TrimWindow
+	Quality    domain.QualityScore
+	Attempts   []AttemptRecord
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+type AttemptRecord struct {
+	Attempt   domain.EncodeAttempt
+	SizeBytes int64
+	Output    domain.MediaInfo
+	Issues    []domain.ValidationIssue
+	Err       error
+	LogPath   string
+	Duration  time.Duration
 }
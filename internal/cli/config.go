@@ -1,12 +1,38 @@
 package cli
 
-import "github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+import (
+	"time"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/selection"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
+)
 
 type WizardConfig struct {
-	Target     target.TargetType
-	InputPath  string
-	InputIsDir bool
-	OutputDir  string
+	Target      target.TargetType
+	Inputs      []selection.SelectionItem
+	OutputDir   string
+	MirrorTree  bool
+	Scan        infra.ScanOptions
+	Collision   job.CollisionPolicy
+	Duration    domain.DurationPolicy
+	Window      domain.TrimWindow
+	AutoSegment bool
+	ChromaKey   domain.ChromaKey
+	Crop        domain.CropOptions
+	Mode        domain.EncodeMode
+	CRF         int
+	Tuning      domain.VPXTuning
+	Quality     domain.QualityMetric
+	TimeBudget  time.Duration
+	Report      ReportOptions
+}
+
+type ReportOptions struct {
+	Path       string
+	EventsPath string
 }
 
 type RunResult struct {
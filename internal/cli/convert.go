@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/selection"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
+)
+
+const convertCommand = "convert"
+
+func RunConvert(ctx context.Context, args []string, out io.Writer, errOut io.Writer) (RunResult, error) {
+	cfg, err := ParseConvertArgs(args, errOut)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return RunResult{}, nil
+		}
+		return RunResult{}, err
+	}
+
+	expanded, err := newExpander(cfg).Expand(cfg.Inputs, cfg.OutputDir)
+	if err != nil {
+		return RunResult{}, err
+	}
+	plan, err := planFromExpanded(cfg, expanded)
+	if err != nil {
+		return RunResult{}, err
+	}
+
+	fmt.Fprintln(out, buildPlanSummary(plan))
+	fmt.Fprintln(out, "")
+
+	tasks := buildTasks(plan.FilteredJobs, plan.Config.Target)
+	return runTasks(ctx, out, NewExecutor(plan.Config), tasks, plan.Config.Report)
+}
+
+func ParseConvertArgs(args []string, errOut io.Writer) (WizardConfig, error) {
+	fs := flag.NewFlagSet("rtts convert", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	targetName := fs.String("target", string(target.TargetVideoSticker), "target: video_sticker, static_sticker, emoji, video_emoji, thumbnail or animated_sticker")
+	outputDir := fs.String("output", "./output", "output directory")
+	var include, exclude patternList
+	fs.Var(&include, "include", "only scan files matching this glob (repeatable)")
+	fs.Var(&exclude, "exclude", "skip paths matching this glob (repeatable)")
+	maxDepth := fs.Int("max-depth", 0, "maximum directory scan depth (0 means unlimited)")
+	hidden := fs.Bool("hidden", false, "include hidden files and directories")
+	mirrorTree := fs.Bool("mirror-tree", false, "mirror the input directory tree into the output directory")
+	collision := fs.String("on-collision", string(job.CollisionSuffix), "output name collisions: suffix, mirror or fail")
+	duration := fs.String("duration", string(domain.DurationTrim), "clips longer than the limit: trim, speedup or speedup_capped")
+	maxSpeed := fs.Float64("max-speed", domain.DefaultMaxSpeed, "maximum playback speed-up for speedup_capped")
+	start := fs.String("start", "", "start offset for video inputs, in seconds or [hh:]mm:ss")
+	end := fs.String("end", "", "end time for video inputs, in seconds or [hh:]mm:ss")
+	autoSegment := fs.Bool("auto-segment", false, "pick the most active segment of long videos instead of the start")
+	keyColor := fs.String("chroma-key", "", "remove this background color from videos: green, blue, #RRGGBB or 0xRRGGBB")
+	keySimilarity := fs.Float64("key-similarity", domain.DefaultKeySimilarity, "chroma key similarity (0-1]")
+	keyBlend := fs.Float64("key-blend", domain.DefaultKeyBlend, "chroma key edge blend [0-1]")
+	crop := fs.String("crop", string(domain.CropNone), "video crop: none, square, auto or W:H:X:Y")
+	encodeMode := fs.String("encode-mode", string(domain.EncodeTwoPass), "video rate control: two_pass, cq or single")
+	crf := fs.Int("crf", domain.DefaultCRF, "quality for cq mode (1-63, lower is better)")
+	deadline := fs.String("deadline", domain.DefaultVPXTuning.Deadline, "libvpx deadline: good, best or realtime")
+	cpuUsed := fs.Int("cpu-used", domain.DefaultVPXTuning.CPUUsed, "libvpx cpu-used (-8 to 8, higher is faster)")
+	rowMT := fs.Bool("row-mt", domain.DefaultVPXTuning.RowMT, "libvpx row based multithreading")
+	tileColumns := fs.Int("tile-columns", domain.DefaultVPXTuning.TileColumns, "libvpx log2 tile columns (0-6)")
+	quality := fs.String("quality", "off", "compare several candidates and keep the best: off, ssim or psnr")
+	timeBudget := fs.Duration("time-budget", 0, "maximum encoding time per file, e.g. 2m (0 for no limit)")
+	reportPath := fs.String("report", "", "write a JSON report of every job to this file")
+	eventsPath := fs.String("events", "", "stream task events as NDJSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: rtts convert [flags] path...")
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseInterspersed(fs, args)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+	if len(paths) == 0 {
+		return WizardConfig{}, fmt.Errorf("at least one input path is required")
+	}
+
+	targetType, err := target.ParseTargetType(*targetName)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	if *maxDepth < 0 {
+		return WizardConfig{}, fmt.Errorf("max depth must not be negative")
+	}
+	collisionPolicy, err := job.ParseCollisionPolicy(*collision)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	durationMode, err := domain.ParseDurationMode(*duration)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+	if *maxSpeed < 1 {
+		return WizardConfig{}, fmt.Errorf("max speed must be at least 1")
+	}
+
+	window, err := parseTrimWindow(*start, *end)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	chromaKey, err := parseChromaKey(*keyColor, *keySimilarity, *keyBlend)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	cropOptions, err := domain.ParseCrop(*crop)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	mode, err := domain.ParseEncodeMode(*encodeMode)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+	if *crf < domain.MinCRF || *crf > domain.MaxCRF {
+		return WizardConfig{}, fmt.Errorf("crf must be between %d and %d", domain.MinCRF, domain.MaxCRF)
+	}
+	tuning := domain.VPXTuning{Deadline: *deadline, CPUUsed: *cpuUsed, RowMT: *rowMT, TileColumns: *tileColumns}
+	if err := tuning.Validate(); err != nil {
+		return WizardConfig{}, err
+	}
+
+	metric, err := domain.ParseQualityMetric(*quality)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	if *timeBudget < 0 {
+		return WizardConfig{}, fmt.Errorf("time budget must not be negative")
+	}
+
+	inputs, err := selection.ResolvePaths(paths)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+
+	cfg := WizardConfig{
+		Target:     targetType,
+		Inputs:     inputs,
+		OutputDir:  *outputDir,
+		MirrorTree: *mirrorTree,
+		Scan: infra.ScanOptions{
+			Include:       include,
+			Exclude:       exclude,
+			MaxDepth:      *maxDepth,
+			IncludeHidden: *hidden,
+		},
+		Collision:   collisionPolicy,
+		Duration:    domain.DurationPolicy{Mode: durationMode, MaxSpeed: *maxSpeed},
+		Window:      window,
+		AutoSegment: *autoSegment,
+		ChromaKey:   chromaKey,
+		Crop:        cropOptions,
+		Mode:        mode,
+		CRF:         *crf,
+		Tuning:      tuning,
+		Quality:     metric,
+		TimeBudget:  *timeBudget,
+		Report:      ReportOptions{Path: *reportPath, EventsPath: *eventsPath},
+	}
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = "./output"
+	}
+	return cfg, nil
+}
+
+func parseTrimWindow(start string, end string) (domain.TrimWindow, error) {
+	startSeconds, err := domain.ParseTimestamp(start)
+	if err != nil {
+		return domain.TrimWindow{}, err
+	}
+	endSeconds, err := domain.ParseTimestamp(end)
+	if err != nil {
+		return domain.TrimWindow{}, err
+	}
+	window := domain.TrimWindow{StartSeconds: startSeconds, EndSeconds: endSeconds}
+	if err := window.Validate(); err != nil {
+		return domain.TrimWindow{}, err
+	}
+	return window, nil
+}
+
+func parseChromaKey(color string, similarity float64, blend float64) (domain.ChromaKey, error) {
+	hex, err := domain.ParseKeyColor(color)
+	if err != nil {
+		return domain.ChromaKey{}, err
+	}
+	if hex == "" {
+		return domain.ChromaKey{}, nil
+	}
+	key := domain.ChromaKey{Color: hex, Similarity: similarity, Blend: blend}
+	if err := key.Validate(); err != nil {
+		return domain.ChromaKey{}, err
+	}
+	return key, nil
+}
+
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		consumed := len(args) - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+type patternList []string
+
+func (l *patternList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *patternList) Set(value string) error {
+	*l = append(*l, splitPatterns(value)...)
+	return nil
+}
+
+func splitPatterns(value string) []string {
+	return lo.Compact(lo.Map(strings.Split(value, ","), func(p string, _ int) string {
+		return strings.TrimSpace(p)
+	}))
+}
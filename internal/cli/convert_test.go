@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestParseConvertArgs(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "a.mp4")
+	if err := os.WriteFile(input, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := ParseConvertArgs([]string{"--target", "emoji", input, "--output", "out", "--on-collision", "fail"}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Target != target.TargetEmoji || cfg.OutputDir != "out" || cfg.Collision != job.CollisionFail {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Inputs) != 1 || cfg.Inputs[0].Path != input || cfg.Inputs[0].IsDir {
+		t.Fatalf("unexpected inputs: %+v", cfg.Inputs)
+	}
+
+	multiCfg, err := ParseConvertArgs([]string{root, input}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if multiCfg.Target != target.TargetVideoSticker || multiCfg.OutputDir != "./output" || multiCfg.Collision != job.CollisionSuffix {
+		t.Fatalf("unexpected config: %+v", multiCfg)
+	}
+	if len(multiCfg.Inputs) != 2 || !multiCfg.Inputs[0].IsDir || multiCfg.Inputs[1].IsDir {
+		t.Fatalf("unexpected inputs: %+v", multiCfg.Inputs)
+	}
+}
+
+func TestParseConvertArgsScanOptions(t *testing.T) {
+	root := t.TempDir()
+	args := []string{"--include", "*.mp4,*.gif", "--exclude", "raw/**", "--exclude", "*~", "--max-depth", "2", "--hidden", root}
+
+	cfg, err := ParseConvertArgs(args, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(cfg.Scan.Include) != 2 || len(cfg.Scan.Exclude) != 2 {
+		t.Fatalf("unexpected patterns: %+v", cfg.Scan)
+	}
+	if cfg.Scan.MaxDepth != 2 || !cfg.Scan.IncludeHidden {
+		t.Fatalf("unexpected scan options: %+v", cfg.Scan)
+	}
+}
+
+func TestParseConvertArgsDuration(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := ParseConvertArgs([]string{"--duration", "speedup_capped", "--max-speed", "1.5", root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Duration.Mode != domain.DurationSpeedUpCapped || cfg.Duration.MaxSpeed != 1.5 {
+		t.Fatalf("unexpected duration policy: %+v", cfg.Duration)
+	}
+}
+
+func TestParseConvertArgsTrimWindow(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := ParseConvertArgs([]string{"--start", "0:01.5", "--end", "4", "--auto-segment", root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Window != (domain.TrimWindow{StartSeconds: 1.5, EndSeconds: 4}) || !cfg.AutoSegment {
+		t.Fatalf("unexpected window: %+v auto=%v", cfg.Window, cfg.AutoSegment)
+	}
+}
+
+func TestParseConvertArgsChromaKey(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := ParseConvertArgs([]string{"--chroma-key", "#00b140", "--key-similarity", "0.2", root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := domain.ChromaKey{Color: "0x00B140", Similarity: 0.2, Blend: domain.DefaultKeyBlend}
+	if cfg.ChromaKey != want {
+		t.Fatalf("unexpected chroma key: %+v", cfg.ChromaKey)
+	}
+}
+
+func TestParseConvertArgsCrop(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := ParseConvertArgs([]string{"--crop", "640:360:0:60", root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Crop.Mode != domain.CropManual || cfg.Crop.Rect != (domain.CropRect{Width: 640, Height: 360, Y: 60}) {
+		t.Fatalf("unexpected crop: %+v", cfg.Crop)
+	}
+}
+
+func TestParseConvertArgsEncoder(t *testing.T) {
+	root := t.TempDir()
+	cfg, err := ParseConvertArgs([]string{root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Mode != domain.EncodeTwoPass || cfg.Tuning != domain.DefaultVPXTuning {
+		t.Fatalf("unexpected encoder defaults: %s %+v", cfg.Mode, cfg.Tuning)
+	}
+
+	cfg, err = ParseConvertArgs([]string{"--encode-mode", "cq", "--crf", "28", "--deadline", "realtime", "--cpu-used", "6", "--quality", "ssim", "--time-budget", "90s", "--report", "r.json", "--events", "e.ndjson", root}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Mode != domain.EncodeConstrainedQuality || cfg.CRF != 28 || cfg.Tuning.Deadline != "realtime" || cfg.Tuning.CPUUsed != 6 || cfg.Quality != domain.QualitySSIM || cfg.TimeBudget != 90*time.Second || cfg.Report != (ReportOptions{Path: "r.json", EventsPath: "e.ndjson"}) {
+		t.Fatalf("unexpected encoder config: %+v", cfg)
+	}
+
+	if _, err := ParseConvertArgs([]string{"--encode-mode", "cq", "--crf", "0", root}, io.Discard); err == nil || !strings.Contains(err.Error(), "crf must be between 1") {
+		t.Fatalf("expected crf 0 to be rejected, got %v", err)
+	}
+}
+
+func TestParseConvertArgsErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"--target", "sticker", "a.mp4"},
+		{"--duration", "stretch", "a.mp4"},
+		{"--max-speed", "0.5", "a.mp4"},
+		{"--start", "5", "--end", "2", "a.mp4"},
+		{"--start", "later", "a.mp4"},
+		{"--chroma-key", "teal-ish", "a.mp4"},
+		{"--crop", "wide", "a.mp4"},
+		{"--encode-mode", "three_pass", "a.mp4"},
+		{"--crf", "80", "a.mp4"},
+		{"--deadline", "soon", "a.mp4"},
+		{"--quality", "vmaf", "a.mp4"},
+		{"--time-budget", "-1m", "a.mp4"},
+		{"--chroma-key", "green", "--key-similarity", "0", "a.mp4"},
+		{filepath.Join(t.TempDir(), "missing.mp4")},
+	}
+	for _, args := range cases {
+		if _, err := ParseConvertArgs(args, io.Discard); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
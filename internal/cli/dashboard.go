@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+)
+
+const (
+	dashboardBarWidth     = 30
+	dashboardMinFailRows  = 3
+	dashboardChromeRows   = 8
+	dashboardTickInterval = time.Second
+)
+
+func useDashboard(out io.Writer) bool {
+	if os.Getenv("ACCESSIBLE") != "" {
+		return false
+	}
+	file, ok := out.(*os.File)
+	return ok && term.IsTerminal(file.Fd())
+}
+
+func showDashboard(out io.Writer, events <-chan task.TaskEvent, cancel context.CancelFunc, total int) ([]task.Result, error) {
+	model := newDashboardModel(total, events, cancel, time.Now())
+	program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(out))
+	final, err := program.Run()
+	if err != nil {
+		return nil, err
+	}
+	model = final.(dashboardModel)
+	for _, result := range model.results {
+		if !resultSucceeded(result) {
+			printResult(out, result)
+		}
+	}
+	return model.results, model.err()
+}
+
+type workerState struct {
+	Label    string
+	Progress task.ProgressEvent
+	Active   bool
+}
+
+type dashboardEventMsg task.TaskEvent
+
+type dashboardClosedMsg struct{}
+
+type dashboardTickMsg time.Time
+
+type dashboardModel struct {
+	events    <-chan task.TaskEvent
+	cancel    context.CancelFunc
+	start     time.Time
+	now       time.Time
+	total     int
+	done      int
+	failed    int
+	workers   []workerState
+	results   []task.Result
+	failures  []task.Result
+	offset    int
+	height    int
+	cancelled bool
+}
+
+func newDashboardModel(total int, events <-chan task.TaskEvent, cancel context.CancelFunc, start time.Time) dashboardModel {
+	return dashboardModel{
+		events:  events,
+		cancel:  cancel,
+		start:   start,
+		now:     start,
+		total:   total,
+		results: make([]task.Result, total),
+	}
+}
+
+func (m dashboardModel) err() error {
+	if m.cancelled {
+		return huh.ErrUserAborted
+	}
+	return nil
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return tea.Batch(waitForTaskEvent(m.events), dashboardTick())
+}
+
+func waitForTaskEvent(events <-chan task.TaskEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return dashboardClosedMsg{}
+		}
+		return dashboardEventMsg(event)
+	}
+}
+
+func dashboardTick() tea.Cmd {
+	return tea.Tick(dashboardTickInterval, func(t time.Time) tea.Msg {
+		return dashboardTickMsg(t)
+	})
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case dashboardEventMsg:
+		m = m.apply(task.TaskEvent(msg))
+		return m, waitForTaskEvent(m.events)
+	case dashboardClosedMsg:
+		return m, tea.Quit
+	case dashboardTickMsg:
+		m.now = time.Time(msg)
+		return m, dashboardTick()
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.offset = min(m.offset, m.maxOffset())
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			if !m.cancelled && m.cancel != nil {
+				m.cancel()
+			}
+			m.cancelled = true
+		case "up", "k":
+			m.offset = max(m.offset-1, 0)
+		case "down", "j":
+			m.offset = min(m.offset+1, m.maxOffset())
+		case "pgup":
+			m.offset = max(m.offset-m.failureRows(), 0)
+		case "pgdown":
+			m.offset = min(m.offset+m.failureRows(), m.maxOffset())
+		}
+	}
+	return m, nil
+}
+
+func (m dashboardModel) apply(event task.TaskEvent) dashboardModel {
+	if event.Worker >= len(m.workers) {
+		m.workers = append(m.workers, make([]workerState, event.Worker+1-len(m.workers))...)
+	}
+	worker := &m.workers[event.Worker]
+	switch event.Type {
+	case task.TaskStarted:
+		*worker = workerState{Label: event.Task.Label, Active: true}
+	case task.TaskProgress:
+		worker.Label = event.Task.Label
+		worker.Progress = event.Progress
+		worker.Active = true
+	case task.TaskFinished:
+		*worker = workerState{}
+		if event.Task.ID >= 0 && event.Task.ID < len(m.results) {
+			m.results[event.Task.ID] = event.Result
+		}
+		m.done++
+		if !resultSucceeded(event.Result) {
+			m.failed++
+			m.failures = append(m.failures, event.Result)
+		}
+	}
+	return m
+}
+
+func (m dashboardModel) completed() float64 {
+	completed := float64(m.done)
+	for _, worker := range m.workers {
+		if worker.Active {
+			completed += min(worker.Progress.Percent, 100) / 100
+		}
+	}
+	return completed
+}
+
+func (m dashboardModel) eta() (time.Duration, bool) {
+	completed := m.completed()
+	elapsed := m.now.Sub(m.start)
+	if completed <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+	remaining := float64(m.total) - completed
+	return time.Duration(float64(elapsed) / completed * remaining).Round(time.Second), true
+}
+
+func (m dashboardModel) throughput() float64 {
+	elapsed := m.now.Sub(m.start).Minutes()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(m.done) / elapsed
+}
+
+func (m dashboardModel) failureLines() []string {
+	lines := make([]string, 0, len(m.failures))
+	for _, result := range m.failures {
+		lines = append(lines, fmt.Sprintf("[FAIL] %s (%s)", result.InputPath, failureMessage(result)))
+		for _, issue := range result.Issues {
+			lines = append(lines, fmt.Sprintf("  - %s: %s", issue.Code, issue.Message))
+		}
+	}
+	return lines
+}
+
+func (m dashboardModel) failureRows() int {
+	rows := m.height - dashboardChromeRows - len(m.workers)
+	return max(rows, dashboardMinFailRows)
+}
+
+func (m dashboardModel) maxOffset() int {
+	return max(len(m.failureLines())-m.failureRows(), 0)
+}
+
+func (m dashboardModel) View() string {
+	var b strings.Builder
+	percent := 0.0
+	if m.total > 0 {
+		percent = m.completed() / float64(m.total) * 100
+	}
+	fmt.Fprintf(&b, "Processing %d/%d  success=%d failed=%d\n", m.done, m.total, m.done-m.failed, m.failed)
+	fmt.Fprintf(&b, "%s %3.0f%%", renderProgressBar(percent, dashboardBarWidth), percent)
+	if eta, ok := m.eta(); ok {
+		fmt.Fprintf(&b, "  ETA %s", eta)
+	}
+	fmt.Fprintf(&b, "  %.1f files/min\n\n", m.throughput())
+
+	for i, worker := range m.workers {
+		if !worker.Active {
+			fmt.Fprintf(&b, "#%d idle\n", i+1)
+			continue
+		}
+		fmt.Fprintf(&b, "#%d %s\n", i+1, strings.TrimPrefix(formatProgress(worker.Label, worker.Progress), "[PROG] "))
+	}
+
+	lines := m.failureLines()
+	fmt.Fprintf(&b, "\nFailures (%d)\n", m.failed)
+	offset := min(m.offset, m.maxOffset())
+	end := min(offset+m.failureRows(), len(lines))
+	for _, line := range lines[offset:end] {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.cancelled {
+		b.WriteString("Cancelling, waiting for running tasks...")
+	} else {
+		b.WriteString("up/down scroll failures, q to cancel")
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestDashboardTracksWorkersAndFailures(t *testing.T) {
+	start := time.Unix(0, 0)
+	m := newDashboardModel(3, nil, nil, start)
+	events := []task.TaskEvent{
+		{Type: task.TaskStarted, Worker: 0, Task: task.Task{ID: 0, Label: "a.mp4"}},
+		{Type: task.TaskStarted, Worker: 1, Task: task.Task{ID: 1, Label: "b.mp4"}},
+		{Type: task.TaskProgress, Worker: 1, Task: task.Task{ID: 1, Label: "b.mp4"}, Progress: task.ProgressEvent{Percent: 50, Attempt: 2, Attempts: 4, Width: 512, Height: 512, BitrateKbps: 480}},
+		{Type: task.TaskFinished, Worker: 0, Task: task.Task{ID: 0, Label: "a.mp4"}, Result: task.Result{
+			InputPath: "a.mp4",
+			Err:       errors.New("validation failed"),
+			Issues:    []domain.ValidationIssue{{Code: "size", Message: "size exceeds limit"}},
+		}},
+	}
+	for _, event := range events {
+		next, _ := m.Update(dashboardEventMsg(event))
+		m = next.(dashboardModel)
+	}
+	next, _ := m.Update(dashboardTickMsg(start.Add(time.Minute)))
+	m = next.(dashboardModel)
+
+	view := m.View()
+	for _, want := range []string{
+		"Processing 1/3  success=0 failed=1",
+		"ETA 1m0s",
+		"1.0 files/min",
+		"#1 idle",
+		"#2 b.mp4 [##########----------]  50% attempt 2/4 512x512 @ 480k",
+		"[FAIL] a.mp4 (validation failed)",
+		"  - size: size exceeds limit",
+	} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view is missing %q:\n%s", want, view)
+		}
+	}
+	if m.results[0].InputPath != "a.mp4" {
+		t.Fatalf("result was not recorded: %+v", m.results)
+	}
+}
+
+func TestDashboardScrollsFailures(t *testing.T) {
+	m := newDashboardModel(10, nil, nil, time.Unix(0, 0))
+	for i := range 10 {
+		event := task.TaskEvent{Type: task.TaskFinished, Task: task.Task{ID: i}, Result: task.Result{InputPath: string(rune('a' + i)), Err: errors.New("boom")}}
+		next, _ := m.Update(dashboardEventMsg(event))
+		m = next.(dashboardModel)
+	}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 14})
+	m = next.(dashboardModel)
+	for range 20 {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = next.(dashboardModel)
+	}
+	if m.offset != m.maxOffset() || m.offset == 0 {
+		t.Fatalf("unexpected offset %d (max %d)", m.offset, m.maxOffset())
+	}
+	view := m.View()
+	if strings.Contains(view, "[FAIL] a (boom)") || !strings.Contains(view, "[FAIL] j (boom)") {
+		t.Fatalf("expected the view scrolled to the end:\n%s", view)
+	}
+}
+
+func TestDashboardCancelsOnQuit(t *testing.T) {
+	cancelled := false
+	m := newDashboardModel(1, nil, func() { cancelled = true }, time.Unix(0, 0))
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = next.(dashboardModel)
+	if !cancelled || !m.cancelled || !strings.Contains(m.View(), "Cancelling") {
+		t.Fatalf("expected cancellation, got %v", m.cancelled)
+	}
+	if !errors.Is(m.err(), huh.ErrUserAborted) {
+		t.Fatalf("expected user abort, got %v", m.err())
+	}
+}
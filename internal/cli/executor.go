@@ -8,11 +8,28 @@ import (
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
 )
 
-func NewExecutor() task.Executor {
+func NewExecutor(cfg WizardConfig) task.Executor {
 	videoPipeline := pipeline.Pipeline{
-		Probe:  infra.FFprobeRunner{},
-		Encode: infra.FFmpegRunner{},
+		Probe:       infra.FFprobeRunner{},
+		Encode:      infra.FFmpegRunner{},
+		Segments:    infra.SceneAnalyzer{},
+		Crops:       infra.CropDetector{},
+		Meter:       infra.QualityMeter{},
+		Target:      target.TargetVideoSticker,
+		Duration:    cfg.Duration,
+		ChromaKey:   cfg.ChromaKey,
+		Crop:        cfg.Crop,
+		Mode:        cfg.Mode,
+		CRF:         cfg.CRF,
+		Tuning:      cfg.Tuning,
+		Quality:     cfg.Quality,
+		TimeBudget:  cfg.TimeBudget,
+		AutoSegment: cfg.AutoSegment,
 	}
+	videoEmojiPipeline := videoPipeline
+	videoEmojiPipeline.Target = target.TargetVideoEmoji
+	thumbnailPipeline := videoPipeline
+	thumbnailPipeline.Target = target.TargetThumbnail
 	imagePipeline := pipeline.ImagePipeline{Encode: infra.FFmpegRunner{}}
 
 	return task.Executor{
@@ -26,6 +43,12 @@ func NewExecutor() task.Executor {
 				Pipeline: imagePipeline,
 				Target:   target.TargetEmoji,
 			},
+			task.TaskTypeVideoEmoji:      handler.VideoStickerHandler{Pipeline: videoEmojiPipeline},
+			task.TaskTypeAnimatedSticker: handler.LottieHandler{Pipeline: pipeline.LottiePipeline{}},
+			task.TaskTypeThumbnail: handler.ThumbnailHandler{
+				Image: imagePipeline,
+				Video: thumbnailPipeline,
+			},
 		},
 	}
 }
@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type runReport struct {
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+	DurationMS int64       `json:"duration_ms"`
+	Total      int         `json:"total"`
+	Succeeded  int         `json:"succeeded"`
+	Failed     int         `json:"failed"`
+	Canceled   int         `json:"canceled"`
+	Jobs       []jobReport `json:"jobs"`
+}
+
+type jobReport struct {
+	Input      string          `json:"input"`
+	Kind       string          `json:"kind,omitempty"`
+	Output     string          `json:"output,omitempty"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	Issues     []issueReport   `json:"issues,omitempty"`
+	Warnings   []issueReport   `json:"warnings,omitempty"`
+	Media      *mediaReport    `json:"media,omitempty"`
+	Segment    *windowReport   `json:"segment,omitempty"`
+	Quality    *qualityReport  `json:"quality,omitempty"`
+	Attempts   []attemptReport `json:"attempts,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+type issueReport struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type mediaReport struct {
+	Width           int     `json:"width"`
+	Height          int     `json:"height"`
+	FPS             float64 `json:"fps"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	HasAudio        bool    `json:"has_audio"`
+	HasAlpha        bool    `json:"has_alpha"`
+	FormatName      string  `json:"format_name,omitempty"`
+	CodecName       string  `json:"codec_name,omitempty"`
+	PixelFormat     string  `json:"pixel_format,omitempty"`
+	BitrateBps      int64   `json:"bitrate_bps,omitempty"`
+	SizeBytes       int64   `json:"size_bytes,omitempty"`
+}
+
+type windowReport struct {
+	StartSeconds float64 `json:"start_seconds"`
+	EndSeconds   float64 `json:"end_seconds"`
+}
+
+type qualityReport struct {
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+}
+
+type attemptReport struct {
+	Width           int           `json:"width"`
+	Height          int           `json:"height"`
+	FPS             int           `json:"fps"`
+	BitrateKbps     int           `json:"bitrate_kbps"`
+	DurationSeconds int           `json:"duration_seconds"`
+	Speed           float64       `json:"speed,omitempty"`
+	Alpha           bool          `json:"alpha,omitempty"`
+	Mode            string        `json:"mode,omitempty"`
+	CRF             int           `json:"crf,omitempty"`
+	SizeBytes       int64         `json:"size_bytes"`
+	Output          *mediaReport  `json:"output,omitempty"`
+	Issues          []issueReport `json:"issues,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	LogPath         string        `json:"log_path,omitempty"`
+	DurationMS      int64         `json:"duration_ms"`
+}
+
+type eventReport struct {
+	Type     string          `json:"type"`
+	Time     time.Time       `json:"time"`
+	Worker   int             `json:"worker"`
+	TaskID   int             `json:"task_id"`
+	Input    string          `json:"input"`
+	Progress *progressReport `json:"progress,omitempty"`
+	Result   *jobReport      `json:"result,omitempty"`
+}
+
+type progressReport struct {
+	Percent     float64 `json:"percent"`
+	Attempt     int     `json:"attempt"`
+	Attempts    int     `json:"attempts"`
+	BitrateKbps int     `json:"bitrate_kbps"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	SizeBytes   int64   `json:"size_bytes"`
+}
+
+func buildRunReport(tasks []task.Task, results []task.Result, startedAt time.Time, finishedAt time.Time) runReport {
+	report := runReport{
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMS: finishedAt.Sub(startedAt).Milliseconds(),
+		Total:      len(tasks),
+		Jobs:       make([]jobReport, 0, len(tasks)),
+	}
+	for _, t := range tasks {
+		var entry jobReport
+		if t.ID >= 0 && t.ID < len(results) && results[t.ID].InputPath != "" {
+			entry = newJobReport(results[t.ID])
+		} else {
+			entry = canceledJobReport(t)
+		}
+		switch entry.Status {
+		case "ok":
+			report.Succeeded++
+		case "canceled":
+			report.Canceled++
+		default:
+			report.Failed++
+		}
+		report.Jobs = append(report.Jobs, entry)
+	}
+	return report
+}
+
+func canceledJobReport(t task.Task) jobReport {
+	input := t.Label
+	if input == "" {
+		input = t.Job.InputPath
+	}
+	return jobReport{Input: input, Kind: string(t.Job.Kind), Status: "canceled"}
+}
+
+func newJobReport(result task.Result) jobReport {
+	report := jobReport{
+		Input:    result.InputPath,
+		Kind:     string(result.Kind),
+		Output:   result.OutputPath,
+		Status:   "ok",
+		Issues:   newIssueReports(result.Issues),
+		Warnings: newIssueReports(result.Warnings),
+		Attempts: lo.Map(result.Attempts, func(r task.AttemptRecord, _ int) attemptReport { return newAttemptReport(r) }),
+	}
+	if !resultSucceeded(result) {
+		report.Status = "failed"
+		if errors.Is(result.Err, context.Canceled) {
+			report.Status = "canceled"
+		}
+		report.Error = failureMessage(result)
+	}
+	if result.Media != (domain.MediaInfo{}) {
+		report.Media = newMediaReport(result.Media)
+	}
+	if !result.Segment.IsZero() {
+		report.Segment = &windowReport{StartSeconds: result.Segment.StartSeconds, EndSeconds: result.Segment.EndSeconds}
+	}
+	if result.Quality.Metric != domain.QualityOff {
+		report.Quality = &qualityReport{Metric: string(result.Quality.Metric), Value: result.Quality.Value}
+	}
+	if !result.StartedAt.IsZero() {
+		report.StartedAt = &result.StartedAt
+		report.FinishedAt = &result.FinishedAt
+		report.DurationMS = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+	}
+	return report
+}
+
+func newIssueReports(issues []domain.ValidationIssue) []issueReport {
+	return lo.Map(issues, func(issue domain.ValidationIssue, _ int) issueReport {
+		return issueReport{Code: issue.Code, Message: issue.Message}
+	})
+}
+
+func newMediaReport(info domain.MediaInfo) *mediaReport {
+	return &mediaReport{
+		Width:           info.Width,
+		Height:          info.Height,
+		FPS:             info.FPS,
+		DurationSeconds: info.DurationSeconds,
+		HasAudio:        info.HasAudio,
+		HasAlpha:        info.HasAlpha,
+		FormatName:      info.FormatName,
+		CodecName:       info.CodecName,
+		PixelFormat:     info.PixelFormat,
+		BitrateBps:      info.BitrateBps,
+		SizeBytes:       info.InputSizeBytes,
+	}
+}
+
+func newAttemptReport(record task.AttemptRecord) attemptReport {
+	a := record.Attempt
+	report := attemptReport{
+		Width:           a.Width,
+		Height:          a.Height,
+		FPS:             a.FPS,
+		BitrateKbps:     a.BitrateKbps,
+		DurationSeconds: a.DurationSeconds,
+		Alpha:           a.Alpha,
+		Mode:            string(a.Mode),
+		CRF:             a.CRF,
+		SizeBytes:       record.SizeBytes,
+		Issues:          newIssueReports(record.Issues),
+		LogPath:         record.LogPath,
+		DurationMS:      record.Duration.Milliseconds(),
+	}
+	if record.Output != (domain.MediaInfo{}) {
+		report.Output = newMediaReport(record.Output)
+	}
+	if a.Speed != 0 && a.Speed != 1 {
+		report.Speed = a.Speed
+	}
+	if record.Err != nil {
+		report.Error = record.Err.Error()
+	}
+	return report
+}
+
+func newEventReport(event task.TaskEvent, at time.Time) eventReport {
+	report := eventReport{
+		Time:   at,
+		Worker: event.Worker,
+		TaskID: event.Task.ID,
+		Input:  event.Task.Label,
+	}
+	switch event.Type {
+	case task.TaskStarted:
+		report.Type = "started"
+	case task.TaskProgress:
+		report.Type = "progress"
+		p := event.Progress
+		report.Progress = &progressReport{
+			Percent:     p.Percent,
+			Attempt:     p.Attempt,
+			Attempts:    p.Attempts,
+			BitrateKbps: p.BitrateKbps,
+			Width:       p.Width,
+			Height:      p.Height,
+			SizeBytes:   p.SizeBytes,
+		}
+	case task.TaskFinished:
+		report.Type = "finished"
+		result := newJobReport(event.Result)
+		report.Result = &result
+	}
+	return report
+}
+
+func writeRunReport(path string, report runReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+type eventLog struct {
+	out io.WriteCloser
+	enc *json.Encoder
+	mu  sync.Mutex
+	err error
+	now func() time.Time
+}
+
+func openEventLog(path string) (*eventLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("open event stream: %w", err)
+	}
+	return newEventLog(file), nil
+}
+
+func newEventLog(out io.WriteCloser) *eventLog {
+	return &eventLog{out: out, enc: json.NewEncoder(out), now: time.Now}
+}
+
+func (l *eventLog) Tee(events <-chan task.TaskEvent) <-chan task.TaskEvent {
+	if l == nil {
+		return events
+	}
+	forwarded := make(chan task.TaskEvent, cap(events))
+	go func() {
+		defer close(forwarded)
+		for event := range events {
+			l.write(event)
+			forwarded <- event
+		}
+	}()
+	return forwarded
+}
+
+func (l *eventLog) write(event task.TaskEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if err := l.enc.Encode(newEventReport(event, l.now())); err != nil {
+		l.err = fmt.Errorf("write event stream: %w", err)
+	}
+}
+
+func (l *eventLog) Err() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *eventLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.out.Close()
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type reportHandler struct{}
+
+func (reportHandler) Handle(ctx context.Context, taskItem task.Task) task.Result {
+	task.ReportProgress(ctx, task.ProgressEvent{Percent: 50, Attempt: 1, Attempts: 2, BitrateKbps: 600, Width: 512, Height: 512})
+	attempt := domain.EncodeAttempt{Width: 512, Height: 512, FPS: 30, BitrateKbps: 600, DurationSeconds: 3}
+	return task.Result{
+		InputPath: taskItem.Label,
+		Media:     domain.MediaInfo{Width: 1920, Height: 1080, FPS: 30, DurationSeconds: 3},
+		Err:       errors.New("validation failed"),
+		Issues:    []domain.ValidationIssue{{Code: "size", Message: "size exceeds limit"}},
+		Attempts: []task.AttemptRecord{{
+			Attempt:   attempt,
+			SizeBytes: 270000,
+			Issues:    []domain.ValidationIssue{{Code: "size", Message: "size exceeds limit"}},
+		}},
+	}
+}
+
+func TestRunTasksWritesReports(t *testing.T) {
+	dir := t.TempDir()
+	report := ReportOptions{Path: filepath.Join(dir, "report.json"), EventsPath: filepath.Join(dir, "events.ndjson")}
+	executor := task.Executor{Concurrency: 1, Handlers: map[task.TaskType]task.TaskHandler{task.TaskTypeVideoSticker: reportHandler{}}}
+	tasks := []task.Task{{ID: 0, Type: task.TaskTypeVideoSticker, Label: "a.mp4", Job: job.Job{InputPath: "a.mp4", Kind: domain.InputKindVideo}}}
+	if _, err := runTasks(context.Background(), io.Discard, executor, tasks, report); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	data, err := os.ReadFile(report.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var decoded runReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if decoded.Total != 1 || decoded.Failed != 1 || len(decoded.Jobs) != 1 {
+		t.Fatalf("unexpected report: %+v", decoded)
+	}
+	jobReport := decoded.Jobs[0]
+	if jobReport.Input != "a.mp4" || jobReport.Kind != "video" || jobReport.Status != "failed" || jobReport.Media == nil || jobReport.Media.Width != 1920 || jobReport.StartedAt == nil {
+		t.Fatalf("unexpected job report: %+v", jobReport)
+	}
+	if len(jobReport.Attempts) != 1 || jobReport.Attempts[0].BitrateKbps != 600 || jobReport.Attempts[0].SizeBytes != 270000 || jobReport.Attempts[0].Issues[0].Code != "size" {
+		t.Fatalf("unexpected attempts: %+v", jobReport.Attempts)
+	}
+
+	file, err := os.Open(report.EventsPath)
+	if err != nil {
+		t.Fatalf("open events: %v", err)
+	}
+	defer file.Close()
+	var types []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event eventReport
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decode event %q: %v", scanner.Text(), err)
+		}
+		types = append(types, event.Type)
+		if event.Type == "progress" && (event.Progress == nil || event.Progress.Percent != 50) {
+			t.Fatalf("unexpected progress event: %+v", event)
+		}
+		if event.Type == "finished" && (event.Result == nil || event.Result.Status != "failed") {
+			t.Fatalf("unexpected finished event: %+v", event)
+		}
+	}
+	if len(types) != 3 || types[0] != "started" || types[1] != "progress" || types[2] != "finished" {
+		t.Fatalf("unexpected event stream: %v", types)
+	}
+}
+
+type cancelingHandler struct {
+	cancel context.CancelFunc
+}
+
+func (h cancelingHandler) Handle(_ context.Context, taskItem task.Task) task.Result {
+	h.cancel()
+	return task.Result{InputPath: taskItem.Label, OutputPath: "a_sticker.webm"}
+}
+
+func TestRunTasksReportsCanceledTasks(t *testing.T) {
+	dir := t.TempDir()
+	report := ReportOptions{Path: filepath.Join(dir, "report.json")}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	executor := task.Executor{Concurrency: 1, Handlers: map[task.TaskType]task.TaskHandler{task.TaskTypeVideoSticker: cancelingHandler{cancel: cancel}}}
+	tasks := []task.Task{
+		{ID: 0, Type: task.TaskTypeVideoSticker, Label: "a.mp4", Job: job.Job{InputPath: "a.mp4", Kind: domain.InputKindVideo}},
+		{ID: 1, Type: task.TaskTypeVideoSticker, Label: "b.gif", Job: job.Job{InputPath: "b.gif", Kind: domain.InputKindGIF}},
+	}
+	_, _ = runTasks(ctx, io.Discard, executor, tasks, report)
+
+	data, err := os.ReadFile(report.Path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var decoded runReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if decoded.Total != 2 || decoded.Succeeded != 1 || decoded.Canceled != 1 || decoded.Failed != 0 {
+		t.Fatalf("unexpected counts: %+v", decoded)
+	}
+	canceled := decoded.Jobs[1]
+	if canceled.Input != "b.gif" || canceled.Kind != "gif" || canceled.Status != "canceled" {
+		t.Fatalf("unexpected canceled job: %+v", canceled)
+	}
+}
+
+func TestRunTasksWritesReportWhenEventLogFails(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+	dir := t.TempDir()
+	report := ReportOptions{Path: filepath.Join(dir, "report.json"), EventsPath: "/dev/full"}
+	executor := task.Executor{Concurrency: 1, Handlers: map[task.TaskType]task.TaskHandler{task.TaskTypeVideoSticker: reportHandler{}}}
+	tasks := []task.Task{{ID: 0, Type: task.TaskTypeVideoSticker, Label: "a.mp4", Job: job.Job{InputPath: "a.mp4", Kind: domain.InputKindVideo}}}
+	if _, err := runTasks(context.Background(), io.Discard, executor, tasks, report); err == nil {
+		t.Fatalf("expected the event stream error")
+	}
+	if _, err := os.Stat(report.Path); err != nil {
+		t.Fatalf("expected the run report despite the event stream error: %v", err)
+	}
+}
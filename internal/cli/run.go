@@ -2,30 +2,49 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/samber/lo"
 
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/pipeline"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/selection"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
 )
 
 type Plan struct {
 	Config       WizardConfig
 	ExpandResult selection.ExpandResult
 	FilteredJobs []job.Job
+	Collisions   []job.Collision
 }
 
-func Run(ctx context.Context, out io.Writer) (RunResult, error) {
+func Run(ctx context.Context, args []string, out io.Writer) (RunResult, error) {
+	if len(args) == 0 {
+		return RunInteractive(ctx, out)
+	}
+	if args[0] == convertCommand {
+		return RunConvert(ctx, args[1:], out, os.Stderr)
+	}
+	return RunResult{}, fmt.Errorf("unknown command %q (usage: rtts [convert [flags] path...])", args[0])
+}
+
+func RunInteractive(ctx context.Context, out io.Writer) (RunResult, error) {
 	accessible := os.Getenv("ACCESSIBLE") != ""
-	expander := selection.SelectionExpander{}
-	executor := NewExecutor()
 
 	for {
 		if err := ctx.Err(); err != nil {
@@ -37,7 +56,7 @@ func Run(ctx context.Context, out io.Writer) (RunResult, error) {
 			return RunResult{}, err
 		}
 
-		plan, err := buildPlan(accessible, expander, cfg)
+		plan, err := buildPlan(accessible, newExpander(cfg), cfg)
 		if err != nil {
 			if msgErr := ShowMessage(accessible, "Invalid selection", err.Error()); msgErr != nil {
 				return RunResult{}, msgErr
@@ -55,20 +74,18 @@ func Run(ctx context.Context, out io.Writer) (RunResult, error) {
 		}
 
 		tasks := buildTasks(plan.FilteredJobs, plan.Config.Target)
-		return runTasks(ctx, out, executor, tasks)
+		return runTasks(ctx, out, NewExecutor(plan.Config), tasks, plan.Config.Report)
 	}
 }
 
 func buildPlan(accessible bool, expander selection.SelectionExpander, cfg WizardConfig) (Plan, error) {
-	selectionItems := []selection.SelectionItem{{Path: cfg.InputPath, IsDir: cfg.InputIsDir}}
-
 	var expanded selection.ExpandResult
 	var expandErr error
 	spinErr := spinner.New().
 		Title("Scanning inputs...").
 		Accessible(accessible).
 		Action(func() {
-			expanded, expandErr = expander.Expand(selectionItems, cfg.OutputDir)
+			expanded, expandErr = expander.Expand(cfg.Inputs, cfg.OutputDir)
 		}).
 		Run()
 	if spinErr != nil {
@@ -77,7 +94,14 @@ func buildPlan(accessible bool, expander selection.SelectionExpander, cfg Wizard
 	if expandErr != nil {
 		return Plan{}, expandErr
 	}
+	return planFromExpanded(cfg, expanded)
+}
+
+func newExpander(cfg WizardConfig) selection.SelectionExpander {
+	return selection.SelectionExpander{Scan: cfg.Scan, MirrorTree: cfg.MirrorTree, Window: cfg.Window}
+}
 
+func planFromExpanded(cfg WizardConfig, expanded selection.ExpandResult) (Plan, error) {
 	filtered := target.FilterJobsForTarget(expanded.Jobs, cfg.Target)
 	hint := target.EvaluateTarget(target.SummarizeJobs(expanded.Jobs), cfg.Target)
 	if len(expanded.Jobs) == 0 && len(expanded.Skipped) > 0 {
@@ -91,19 +115,72 @@ func buildPlan(accessible bool, expander selection.SelectionExpander, cfg Wizard
 		return Plan{}, fmt.Errorf("no valid inputs")
 	}
 
+	resolved, collisions, err := job.ResolveCollisions(filtered, cfg.Collision, func(j job.Job) []string {
+		return pipeline.OutputPaths(j, cfg.Target)
+	})
+	if err != nil {
+		return Plan{}, fmt.Errorf("output name collision: %w", err)
+	}
+	expanded.OutputDirs = outputDirs(resolved)
+
 	return Plan{
 		Config:       cfg,
 		ExpandResult: expanded,
-		FilteredJobs: filtered,
+		FilteredJobs: resolved,
+		Collisions:   collisions,
 	}, nil
 }
 
+func outputDirs(jobs []job.Job) []string {
+	dirs := lo.Uniq(lo.Map(jobs, func(j job.Job, _ int) string { return filepath.Dir(j.OutputPath) }))
+	sort.Strings(dirs)
+	return dirs
+}
+
 func buildPlanSummary(plan Plan) string {
 	lines := make([]string, 0, 32)
 
 	lines = append(lines, fmt.Sprintf("Target: %s", target.TargetLabel(plan.Config.Target)))
-	lines = append(lines, fmt.Sprintf("Input: %s", plan.Config.InputPath))
+	if len(plan.Config.Inputs) == 1 {
+		lines = append(lines, fmt.Sprintf("Input: %s", plan.Config.Inputs[0].Path))
+	} else {
+		lines = append(lines, fmt.Sprintf("Inputs: %d", len(plan.Config.Inputs)))
+		inputPaths := lo.Map(plan.Config.Inputs, func(item selection.SelectionItem, _ int) string {
+			return item.Path
+		})
+		lines = appendLimited(lines, inputPaths, 8)
+	}
 	lines = append(lines, fmt.Sprintf("Output: %s", plan.Config.OutputDir))
+	if filters := describeScanOptions(plan.Config.Scan); filters != "" {
+		lines = append(lines, fmt.Sprintf("Filters: %s", filters))
+	}
+	if window := describeTrimWindow(plan.Config.Window); window != "" {
+		lines = append(lines, fmt.Sprintf("Trim window: %s (per-file %s sidecars take precedence)", window, infra.SidecarSuffix))
+	}
+	if plan.Config.AutoSegment {
+		lines = append(lines, "Segment: most active part of long videos without a trim window")
+	}
+	if crop := describeCrop(plan.Config.Crop); crop != "" {
+		lines = append(lines, fmt.Sprintf("Crop: %s", crop))
+	}
+	if key := plan.Config.ChromaKey; key.Enabled() {
+		lines = append(lines, fmt.Sprintf("Chroma key: %s (similarity %s, blend %s)", key.Color, strconv.FormatFloat(key.Similarity, 'f', -1, 64), strconv.FormatFloat(key.Blend, 'f', -1, 64)))
+	}
+	if usesVideoPipeline(plan.Config.Target) && plan.Config.Mode != "" {
+		lines = append(lines, fmt.Sprintf("Rate control: %s", describeEncodeMode(plan.Config)))
+	}
+	if usesVideoPipeline(plan.Config.Target) && plan.Config.Quality != domain.QualityOff && !plan.Config.ChromaKey.Enabled() {
+		lines = append(lines, fmt.Sprintf("Quality: best of %d candidates by %s", domain.MaxQualityCandidates, plan.Config.Quality))
+	}
+	if usesVideoPipeline(plan.Config.Target) && plan.Config.TimeBudget > 0 {
+		lines = append(lines, fmt.Sprintf("Time budget: %s per file", plan.Config.TimeBudget))
+	}
+	if duration := describeDurationPolicy(plan.Config.Duration); duration != "" {
+		lines = append(lines, fmt.Sprintf("Duration: %s", duration))
+	}
+	if plan.Config.MirrorTree {
+		lines = append(lines, fmt.Sprintf("Output directories: %d (mirroring input tree)", len(plan.ExpandResult.OutputDirs)))
+	}
 	lines = append(lines, "")
 
 	lines = append(lines, fmt.Sprintf("Directories: %d", plan.ExpandResult.DirCount))
@@ -111,87 +188,317 @@ func buildPlanSummary(plan Plan) string {
 	lines = append(lines, fmt.Sprintf("Total supported files: %d", plan.ExpandResult.TotalFiles))
 	lines = append(lines, fmt.Sprintf("Tasks for target: %d", len(plan.FilteredJobs)))
 
+	if len(plan.Collisions) > 0 {
+		lines = append(lines, "")
+		policy := plan.Config.Collision
+		if policy == "" {
+			policy = job.CollisionSuffix
+		}
+		lines = append(lines, fmt.Sprintf("Output collisions: %d (resolved by %s)", len(plan.Collisions), policy))
+		collided := lo.Map(plan.Collisions, func(c job.Collision, _ int) string {
+			return fmt.Sprintf("%s <- %s", c.OutputPath, strings.Join(c.Inputs, ", "))
+		})
+		lines = appendLimited(lines, collided, 8)
+	}
+
+	if len(plan.ExpandResult.Warnings) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("Warnings: %d", len(plan.ExpandResult.Warnings)))
+		warnings := lo.Map(plan.ExpandResult.Warnings, func(w job.Warning, _ int) string {
+			return fmt.Sprintf("%s (%s)", w.Path, w.Message)
+		})
+		lines = appendLimited(lines, warnings, 8)
+	}
+
 	if len(plan.ExpandResult.Skipped) > 0 {
 		lines = append(lines, "")
 		lines = append(lines, fmt.Sprintf("Skipped: %d", len(plan.ExpandResult.Skipped)))
-		max := 8
-		if len(plan.ExpandResult.Skipped) < max {
-			max = len(plan.ExpandResult.Skipped)
-		}
-		for _, s := range plan.ExpandResult.Skipped[:max] {
-			lines = append(lines, fmt.Sprintf("- %s (%s)", s.Path, s.Reason))
-		}
-		if len(plan.ExpandResult.Skipped) > max {
-			lines = append(lines, "- ...")
-		}
+		skipped := lo.Map(plan.ExpandResult.Skipped, func(s job.Skipped, _ int) string {
+			return fmt.Sprintf("%s (%s)", s.Path, s.Reason)
+		})
+		lines = appendLimited(lines, skipped, 8)
 	}
 
 	return strings.Join(lines, "\n")
 }
 
-func runTasks(ctx context.Context, out io.Writer, executor task.Executor, tasks []task.Task) (RunResult, error) {
+func describeScanOptions(opts infra.ScanOptions) string {
+	parts := make([]string, 0, 4)
+	if len(opts.Include) > 0 {
+		parts = append(parts, "include "+strings.Join(opts.Include, ","))
+	}
+	if len(opts.Exclude) > 0 {
+		parts = append(parts, "exclude "+strings.Join(opts.Exclude, ","))
+	}
+	if opts.MaxDepth > 0 {
+		parts = append(parts, fmt.Sprintf("max depth %d", opts.MaxDepth))
+	}
+	if opts.IncludeHidden {
+		parts = append(parts, "hidden files included")
+	}
+	return strings.Join(parts, "; ")
+}
+
+func describeDurationPolicy(policy domain.DurationPolicy) string {
+	switch policy.Mode {
+	case domain.DurationSpeedUp:
+		return "speed up to fit"
+	case domain.DurationSpeedUpCapped:
+		return fmt.Sprintf("speed up to fit (max %sx), then trim", strconv.FormatFloat(policy.MaxSpeed, 'f', -1, 64))
+	default:
+		return ""
+	}
+}
+
+func describeTrimWindow(window domain.TrimWindow) string {
+	if window.IsZero() {
+		return ""
+	}
+	if window.EndSeconds == 0 {
+		return fmt.Sprintf("from %s", domain.FormatSeconds(window.StartSeconds))
+	}
+	return fmt.Sprintf("%s to %s", domain.FormatSeconds(window.StartSeconds), domain.FormatSeconds(window.EndSeconds))
+}
+
+func describeCrop(crop domain.CropOptions) string {
+	switch crop.Mode {
+	case domain.CropCenterSquare:
+		return "center square"
+	case domain.CropAuto:
+		return "auto-detect black bars"
+	case domain.CropManual:
+		return crop.Rect.String()
+	default:
+		return ""
+	}
+}
+
+func describeEncodeMode(cfg WizardConfig) string {
+	switch cfg.Mode {
+	case domain.EncodeConstrainedQuality:
+		return fmt.Sprintf("constrained quality (crf %d)", cfg.CRF)
+	case domain.EncodeSinglePass:
+		return "single pass"
+	default:
+		return "two pass"
+	}
+}
+
+func appendLimited(lines []string, items []string, max int) []string {
+	if len(items) < max {
+		max = len(items)
+	}
+	for _, item := range items[:max] {
+		lines = append(lines, "- "+item)
+	}
+	if len(items) > max {
+		lines = append(lines, "- ...")
+	}
+	return lines
+}
+
+func runTasks(ctx context.Context, out io.Writer, executor task.Executor, tasks []task.Task, report ReportOptions) (RunResult, error) {
 	if len(tasks) == 0 {
 		fmt.Fprintln(out, "No tasks to run.")
 		return RunResult{}, nil
 	}
 
-	fmt.Fprintf(out, "Processing %d task(s). Press Ctrl+C to cancel.\n", len(tasks))
+	eventLog, err := openEventLog(report.EventsPath)
+	if err != nil {
+		return RunResult{}, err
+	}
+	defer eventLog.Close()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	startedAt := time.Now()
 	events := make(chan task.TaskEvent, len(tasks)*2)
 	doneCh := make(chan error, 1)
 	go func() {
-		doneCh <- executor.Run(ctx, tasks, events)
+		doneCh <- executor.Run(runCtx, tasks, events)
 	}()
+	stream := eventLog.Tee(events)
+
+	var results []task.Result
+	if useDashboard(out) {
+		results, err = showDashboard(out, stream, cancel, len(tasks))
+	} else {
+		results = printEvents(out, stream, len(tasks))
+	}
+	if err != nil {
+		cancel()
+		for range stream {
+		}
+	}
+
+	execErr := <-doneCh
+	if err != nil {
+		execErr = err
+	}
+	var reportErr error
+	if report.Path != "" {
+		reportErr = writeRunReport(report.Path, buildRunReport(tasks, results, startedAt, time.Now()))
+	}
+	if err := errors.Join(execErr, eventLog.Err(), reportErr); err != nil {
+		return RunResult{}, err
+	}
+	return summarizeResults(out, results), nil
+}
+
+func printEvents(out io.Writer, events <-chan task.TaskEvent, total int) []task.Result {
+	fmt.Fprintf(out, "Processing %d task(s). Press Ctrl+C to cancel.\n", total)
 
-	results := make([]task.Result, len(tasks))
+	results := make([]task.Result, total)
+	shown := make(map[int]progressStep, total)
 	doneCount := 0
 	for event := range events {
 		switch event.Type {
 		case task.TaskStarted:
 			fmt.Fprintf(out, "[RUN] %s\n", event.Task.Label)
+		case task.TaskProgress:
+			step := newProgressStep(event.Progress)
+			if last, ok := shown[event.Task.ID]; ok && last == step {
+				continue
+			}
+			shown[event.Task.ID] = step
+			fmt.Fprintln(out, formatProgress(event.Task.Label, event.Progress))
 		case task.TaskFinished:
 			if event.Task.ID >= 0 && event.Task.ID < len(results) {
 				results[event.Task.ID] = event.Result
 			}
 			doneCount++
 			printResult(out, event.Result)
-			fmt.Fprintf(out, "Done: %d/%d\n", doneCount, len(tasks))
+			fmt.Fprintf(out, "Done: %d/%d\n", doneCount, total)
 		}
 	}
+	return results
+}
 
-	execErr := <-doneCh
-	if execErr != nil {
-		return RunResult{}, execErr
-	}
-
-	succeeded := lo.CountBy(results, func(r task.Result) bool {
-		return r.Err == nil && len(r.Issues) == 0
-	})
+func summarizeResults(out io.Writer, results []task.Result) RunResult {
+	succeeded := lo.CountBy(results, resultSucceeded)
 	failed := len(results) - succeeded
 
 	fmt.Fprintln(out, "")
 	fmt.Fprintf(out, "Summary: success=%d failed=%d\n", succeeded, failed)
 
-	return RunResult{Total: len(results), Succeeded: succeeded, Failed: failed}, nil
+	return RunResult{Total: len(results), Succeeded: succeeded, Failed: failed}
+}
+
+func resultSucceeded(r task.Result) bool {
+	return r.Err == nil && len(r.Issues) == 0
+}
+
+const (
+	progressBarWidth = 20
+	progressStepSize = 10
+)
+
+type progressStep struct {
+	Attempt int
+	Bucket  int
+}
+
+func newProgressStep(progress task.ProgressEvent) progressStep {
+	return progressStep{Attempt: progress.Attempt, Bucket: int(progress.Percent) / progressStepSize}
+}
+
+func formatProgress(label string, progress task.ProgressEvent) string {
+	line := fmt.Sprintf("[PROG] %s %s %3.0f%%", label, renderProgressBar(progress.Percent, progressBarWidth), progress.Percent)
+	if progress.Attempts > 0 {
+		line += fmt.Sprintf(" attempt %d/%d", progress.Attempt, progress.Attempts)
+	}
+	if progress.Width > 0 && progress.Height > 0 {
+		line += fmt.Sprintf(" %dx%d", progress.Width, progress.Height)
+	}
+	if progress.BitrateKbps > 0 {
+		line += fmt.Sprintf(" @ %dk", progress.BitrateKbps)
+	}
+	return line
+}
+
+func renderProgressBar(percent float64, width int) string {
+	filled := int(math.Round(min(max(percent, 0), 100) / 100 * float64(width)))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
 }
 
 func printResult(out io.Writer, result task.Result) {
-	if result.Err == nil && len(result.Issues) == 0 {
+	if resultSucceeded(result) {
+		kept := ""
+		if !result.Segment.IsZero() {
+			kept = fmt.Sprintf(" (kept %s)", describeTrimWindow(result.Segment))
+		}
+		if result.Quality.Metric != domain.QualityOff {
+			kept += fmt.Sprintf(" (%s %s)", result.Quality.Metric, strconv.FormatFloat(result.Quality.Value, 'f', 4, 64))
+		}
 		if result.OutputPath != "" {
-			fmt.Fprintf(out, "[DONE] %s -> %s\n", result.InputPath, result.OutputPath)
+			fmt.Fprintf(out, "[DONE] %s -> %s%s\n", result.InputPath, result.OutputPath, kept)
 		} else {
-			fmt.Fprintf(out, "[DONE] %s\n", result.InputPath)
+			fmt.Fprintf(out, "[DONE] %s%s\n", result.InputPath, kept)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(out, "[WARN] %s (%s)\n", result.InputPath, w.Message)
 		}
 		return
 	}
 
-	message := ""
+	fmt.Fprintf(out, "[FAIL] %s (%s)\n", result.InputPath, failureMessage(result))
+	printAttemptTable(out, result.Attempts)
+}
+
+const maxAttemptErrorLen = 60
+
+func printAttemptTable(out io.Writer, attempts []task.AttemptRecord) {
+	if len(attempts) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "       #\tscale\tfps\tbitrate\tsize\ttime\tresult")
+	for i, record := range attempts {
+		a := record.Attempt
+		fmt.Fprintf(w, "       %d\t%dx%d\t%d\t%dk\t%s\t%s\t%s\n",
+			i+1, a.Width, a.Height, a.FPS, a.BitrateKbps,
+			formatSize(record.SizeBytes), record.Duration.Round(100*time.Millisecond), describeAttemptResult(record))
+	}
+	w.Flush()
+}
+
+func describeAttemptResult(record task.AttemptRecord) string {
+	if record.Err != nil {
+		if record.LogPath != "" {
+			return "ffmpeg error, log: " + record.LogPath
+		}
+		message, _, _ := strings.Cut(record.Err.Error(), "\n")
+		if len(message) > maxAttemptErrorLen {
+			message = message[:maxAttemptErrorLen] + "..."
+		}
+		return message
+	}
+	if len(record.Issues) == 0 {
+		return "ok"
+	}
+	codes := lo.Map(record.Issues, func(issue domain.ValidationIssue, _ int) string { return issue.Code })
+	return strings.Join(codes, ", ")
+}
+
+func formatSize(bytes int64) string {
+	if bytes <= 0 {
+		return "-"
+	}
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+}
+
+func failureMessage(result task.Result) string {
 	if result.Err != nil {
-		message = result.Err.Error()
-	} else if len(result.Issues) > 0 {
-		message = result.Issues[0].Message
+		return result.Err.Error()
+	}
+	if len(result.Issues) > 0 {
+		return result.Issues[0].Message
 	}
-	fmt.Fprintf(out, "[FAIL] %s (%s)\n", result.InputPath, message)
+	return ""
 }
 
 func buildTasks(jobs []job.Job, targetType target.TargetType) []task.Task {
@@ -213,6 +520,12 @@ func taskTypeForTarget(targetType target.TargetType) task.TaskType {
 		return task.TaskTypeStaticSticker
 	case target.TargetEmoji:
 		return task.TaskTypeEmoji
+	case target.TargetVideoEmoji:
+		return task.TaskTypeVideoEmoji
+	case target.TargetThumbnail:
+		return task.TaskTypeThumbnail
+	case target.TargetAnimatedSticker:
+		return task.TaskTypeAnimatedSticker
 	default:
 		return task.TaskTypeVideoSticker
 	}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/selection"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/task"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type progressHandler struct{}
+
+func (progressHandler) Handle(ctx context.Context, taskItem task.Task) task.Result {
+	for _, percent := range []float64{0, 4, 12, 15, 50, 100} {
+		task.ReportProgress(ctx, task.ProgressEvent{Percent: percent, Attempt: 1, Attempts: 3, BitrateKbps: 480, Width: 512, Height: 512})
+	}
+	return task.Result{InputPath: taskItem.Label}
+}
+
+func TestRunTasksRendersProgress(t *testing.T) {
+	var out bytes.Buffer
+	executor := task.Executor{Concurrency: 1, Handlers: map[task.TaskType]task.TaskHandler{task.TaskTypeVideoSticker: progressHandler{}}}
+	tasks := []task.Task{{ID: 0, Type: task.TaskTypeVideoSticker, Label: "a.mp4"}}
+	if _, err := runTasks(context.Background(), &out, executor, tasks, ReportOptions{}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got := strings.Count(out.String(), "[PROG]"); got != 4 {
+		t.Fatalf("expected 4 progress lines, got %d:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "[PROG] a.mp4 [##########----------]  50% attempt 1/3 512x512 @ 480k") {
+		t.Fatalf("missing progress line:\n%s", out.String())
+	}
+}
+
+func TestRenderProgressBar(t *testing.T) {
+	cases := map[float64]string{-5: "[----]", 0: "[----]", 50: "[##--]", 100: "[####]", 140: "[####]"}
+	for percent, want := range cases {
+		if got := renderProgressBar(percent, 4); got != want {
+			t.Fatalf("bar for %v: got %s want %s", percent, got, want)
+		}
+	}
+}
+
+func TestPrintResultShowsAttemptTable(t *testing.T) {
+	var out bytes.Buffer
+	printResult(&out, task.Result{
+		InputPath: "a.mp4",
+		Err:       errors.New("validation failed"),
+		Issues:    []domain.ValidationIssue{{Code: "size", Message: "size exceeds limit"}},
+		Attempts: []task.AttemptRecord{
+			{
+				Attempt:   domain.EncodeAttempt{Width: 512, Height: 512, FPS: 30, BitrateKbps: 699},
+				SizeBytes: 264 * 1024,
+				Issues:    []domain.ValidationIssue{{Code: "size", Message: "size exceeds limit"}},
+				Duration:  4200 * time.Millisecond,
+			},
+			{
+				Attempt:  domain.EncodeAttempt{Width: 384, Height: 384, FPS: 24, BitrateKbps: 500},
+				Err:      errors.New("ffmpeg failed: exit status 1"),
+				LogPath:  "a_sticker.webm.ffmpeg-error.log",
+				Duration: time.Second,
+			},
+		},
+	})
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a failure line and a three row table, got:\n%s", out.String())
+	}
+	for i, want := range [][]string{
+		{"#", "scale", "fps", "bitrate", "size", "time", "result"},
+		{"1", "512x512", "30", "699k", "264.0 KB", "4.2s", "size"},
+		{"2", "384x384", "24", "500k", "-", "1s", "ffmpeg error, log: a_sticker.webm.ffmpeg-error.log"},
+	} {
+		fields := strings.Join(strings.Fields(lines[i+1]), " ")
+		if fields != strings.Join(want, " ") {
+			t.Fatalf("row %d: got %q want %q", i, fields, strings.Join(want, " "))
+		}
+	}
+}
+
+func TestPlanSummaryDefaultsCollisionPolicy(t *testing.T) {
+	plan := Plan{
+		Config:     WizardConfig{Target: target.TargetVideoSticker},
+		Collisions: []job.Collision{{OutputPath: "out/a_sticker.webm", Inputs: []string{"a.mp4", "a.mov"}}},
+	}
+	if summary := buildPlanSummary(plan); !strings.Contains(summary, "(resolved by suffix)") {
+		t.Fatalf("expected the default policy in the summary:\n%s", summary)
+	}
+}
+
+func TestPlanOutputDirsFollowResolvedPaths(t *testing.T) {
+	expanded := selection.ExpandResult{
+		Jobs: []job.Job{
+			{InputPath: filepath.Join("assets", "a", "x.mp4"), Kind: domain.InputKindVideo, OutputDir: "out", Root: "assets"},
+			{InputPath: filepath.Join("assets", "b", "x.mp4"), Kind: domain.InputKindVideo, OutputDir: "out", Root: "assets"},
+		},
+		OutputDirs: []string{"out"},
+	}
+	plan, err := planFromExpanded(WizardConfig{Target: target.TargetVideoSticker, Collision: job.CollisionMirror, MirrorTree: true}, expanded)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []string{filepath.Join("out", "a"), filepath.Join("out", "b")}
+	if strings.Join(plan.ExpandResult.OutputDirs, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected output dirs: %v", plan.ExpandResult.OutputDirs)
+	}
+}
@@ -1,19 +1,30 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
+	"github.com/samber/lo"
 
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/job"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/selection"
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/app/target"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/infra"
 )
 
 type inputMode string
 
 const (
-	inputModeFile inputMode = "file"
-	inputModeDir  inputMode = "dir"
+	inputModeFile  inputMode = "file"
+	inputModeDir   inputMode = "dir"
+	inputModeMulti inputMode = "multi"
 )
 
 var supportedFileTypes = []string{
@@ -27,6 +38,8 @@ var supportedFileTypes = []string{
 	".jpg",
 	".jpeg",
 	".webp",
+	".json",
+	".tgs",
 }
 
 func RunWizard(accessible bool) (WizardConfig, error) {
@@ -35,7 +48,30 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 
 	var filePath string
 	var dirPath string
+	browseDir := "."
+	var multiPaths []string
 	outputDir := "./output"
+	mirrorTree := false
+	var includePatterns string
+	var excludePatterns string
+	maxDepth := "0"
+	includeHidden := false
+	collision := job.CollisionSuffix
+	durationMode := domain.DurationTrim
+	var trimStart string
+	var trimEnd string
+	describeInput := newDurationDescriber()
+	autoSegment := false
+	var keyColor string
+	cropMode := domain.CropNone
+	encodeMode := domain.EncodeTwoPass
+	quality := domain.QualityOff
+	crf := strconv.Itoa(domain.DefaultCRF)
+	var timeBudget string
+	var cropRect string
+	keySimilarity := strconv.FormatFloat(domain.DefaultKeySimilarity, 'f', -1, 64)
+	keyBlend := strconv.FormatFloat(domain.DefaultKeyBlend, 'f', -1, 64)
+	maxSpeed := strconv.FormatFloat(domain.DefaultMaxSpeed, 'f', -1, 64)
 
 	form := huh.NewForm(
 		huh.NewGroup(
@@ -45,6 +81,9 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 					huh.NewOption(target.TargetLabel(target.TargetVideoSticker), target.TargetVideoSticker),
 					huh.NewOption(target.TargetLabel(target.TargetStaticSticker), target.TargetStaticSticker),
 					huh.NewOption(target.TargetLabel(target.TargetEmoji), target.TargetEmoji),
+					huh.NewOption(target.TargetLabel(target.TargetVideoEmoji), target.TargetVideoEmoji),
+					huh.NewOption(target.TargetLabel(target.TargetThumbnail), target.TargetThumbnail),
+					huh.NewOption(target.TargetLabel(target.TargetAnimatedSticker), target.TargetAnimatedSticker),
 				).
 				Value(&selectedTarget),
 		),
@@ -54,6 +93,7 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 				Options(
 					huh.NewOption("File", inputModeFile),
 					huh.NewOption("Directory", inputModeDir),
+					huh.NewOption("Multiple files and directories", inputModeMulti),
 				).
 				Value(&mode),
 		),
@@ -84,6 +124,37 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 		).WithHideFunc(func() bool {
 			return mode != inputModeDir
 		}),
+		huh.NewGroup(
+			huh.NewFilePicker().
+				Title("Browse directory").
+				Description("Select the directory to pick inputs from.").
+				CurrentDirectory(".").
+				ShowHidden(false).
+				ShowSize(false).
+				FileAllowed(false).
+				DirAllowed(true).
+				Value(&browseDir),
+		).WithHideFunc(func() bool {
+			return mode != inputModeMulti
+		}),
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Inputs").
+				Description("Directories are scanned recursively.").
+				OptionsFunc(func() []huh.Option[string] {
+					return browseOptions(browseDir)
+				}, &browseDir).
+				Filterable(true).
+				Value(&multiPaths).
+				Validate(func(paths []string) error {
+					if len(paths) == 0 {
+						return fmt.Errorf("select at least one input")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool {
+			return mode != inputModeMulti
+		}),
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Output directory").
@@ -91,6 +162,173 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 				Value(&outputDir).
 				Validate(huh.ValidateNotEmpty()),
 		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Include patterns").
+				Description("Comma-separated globs, e.g. *.mp4,characters/**. Empty scans everything.").
+				Value(&includePatterns),
+			huh.NewInput().
+				Title("Exclude patterns").
+				Description("Comma-separated globs. A .rttsignore file in the directory is also honored.").
+				Value(&excludePatterns),
+			huh.NewInput().
+				Title("Max depth").
+				Description("0 means unlimited.").
+				Value(&maxDepth).
+				Validate(validateDepth),
+			huh.NewConfirm().
+				Title("Include hidden files?").
+				Value(&includeHidden),
+		).WithHideFunc(func() bool {
+			return mode == inputModeFile
+		}),
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Mirror input directory tree?").
+				Description("Keep the subfolder structure of scanned directories in the output.").
+				Value(&mirrorTree),
+		).WithHideFunc(func() bool {
+			return mode == inputModeFile
+		}),
+		huh.NewGroup(
+			huh.NewSelect[job.CollisionPolicy]().
+				Title("Output name collisions").
+				Options(
+					huh.NewOption("Add a number suffix", job.CollisionSuffix),
+					huh.NewOption("Mirror the source subdirectory", job.CollisionMirror),
+					huh.NewOption("Stop with an error", job.CollisionFail),
+				).
+				Value(&collision),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Start offset").
+				DescriptionFunc(func() string {
+					if mode != inputModeFile {
+						return "Seconds or [hh:]mm:ss, applied to every video. " + infra.SidecarSuffix + " sidecars take precedence."
+					}
+					return describeInput(filePath)
+				}, &filePath).
+				Placeholder("0").
+				Value(&trimStart).
+				Validate(validateTimestamp),
+			huh.NewInput().
+				Title("End time").
+				Description("Leave empty to use the rest of the clip.").
+				Value(&trimEnd).
+				Validate(func(value string) error {
+					_, err := parseTrimWindow(trimStart, value)
+					return err
+				}),
+			huh.NewConfirm().
+				Title("Pick the most active segment of long videos?").
+				Description("Only used when no start or end is set.").
+				Value(&autoSegment),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget)
+		}),
+		huh.NewGroup(
+			huh.NewSelect[domain.EncodeMode]().
+				Title("Rate control").
+				Options(
+					huh.NewOption("Two pass (closest to the size limit)", domain.EncodeTwoPass),
+					huh.NewOption("Constrained quality", domain.EncodeConstrainedQuality),
+					huh.NewOption("Single pass (fastest)", domain.EncodeSinglePass),
+				).
+				Value(&encodeMode),
+			huh.NewSelect[domain.QualityMetric]().
+				Title("Candidate selection").
+				Options(
+					huh.NewOption("First output within the limits", domain.QualityOff),
+					huh.NewOption("Best of several by SSIM", domain.QualitySSIM),
+					huh.NewOption("Best of several by PSNR", domain.QualityPSNR),
+				).
+				Value(&quality),
+			huh.NewInput().
+				Title("Time budget per file").
+				Description("e.g. 90s or 2m. Leave empty for no limit.").
+				Value(&timeBudget).
+				Validate(validateTimeBudget),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget)
+		}),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("CRF").
+				Description("1-63, lower is better. The bitrate stays capped by the size limit.").
+				Value(&crf).
+				Validate(validateCRF),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget) || encodeMode != domain.EncodeConstrainedQuality
+		}),
+		huh.NewGroup(
+			huh.NewSelect[domain.CropMode]().
+				Title("Crop").
+				Options(
+					huh.NewOption("None", domain.CropNone),
+					huh.NewOption("Center square", domain.CropCenterSquare),
+					huh.NewOption("Auto-detect black bars", domain.CropAuto),
+					huh.NewOption("Manual rectangle", domain.CropManual),
+				).
+				Value(&cropMode),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget)
+		}),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Crop rectangle").
+				Description("W:H:X:Y in source pixels, e.g. 1080:1080:420:0.").
+				Value(&cropRect).
+				Validate(func(value string) error {
+					_, err := domain.ParseCropRect(value)
+					return err
+				}),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget) || cropMode != domain.CropManual
+		}),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Chroma key color").
+				Description("Background color to remove, e.g. green or #00B140. Empty keeps the background.").
+				Value(&keyColor).
+				Validate(func(value string) error {
+					_, err := domain.ParseKeyColor(value)
+					return err
+				}),
+			huh.NewInput().
+				Title("Key similarity").
+				Description("Above 0 up to 1, higher removes more shades around the key color.").
+				Value(&keySimilarity).
+				Validate(validateKeySimilarity),
+			huh.NewInput().
+				Title("Key blend").
+				Description("0-1, softens the edges of the keyed area.").
+				Value(&keyBlend).
+				Validate(validateUnitInterval),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget)
+		}),
+		huh.NewGroup(
+			huh.NewSelect[domain.DurationMode]().
+				Title("Clips longer than the limit").
+				Options(
+					huh.NewOption("Trim", domain.DurationTrim),
+					huh.NewOption("Speed up to fit", domain.DurationSpeedUp),
+					huh.NewOption("Speed up to a maximum, then trim", domain.DurationSpeedUpCapped),
+				).
+				Value(&durationMode),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget)
+		}),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Maximum speed-up").
+				Description("Playback speed factor, e.g. 1.5.").
+				Value(&maxSpeed).
+				Validate(validateSpeed),
+		).WithHideFunc(func() bool {
+			return !usesVideoPipeline(selectedTarget) || durationMode != domain.DurationSpeedUpCapped
+		}),
 	).WithAccessible(accessible)
 
 	if err := form.Run(); err != nil {
@@ -100,24 +338,183 @@ func RunWizard(accessible bool) (WizardConfig, error) {
 	cfg := WizardConfig{
 		Target:     selectedTarget,
 		OutputDir:  strings.TrimSpace(outputDir),
-		InputIsDir: mode == inputModeDir,
+		MirrorTree: mirrorTree,
+		Collision:  collision,
+	}
+	if usesVideoPipeline(selectedTarget) {
+		speed, _ := strconv.ParseFloat(strings.TrimSpace(maxSpeed), 64)
+		cfg.Duration = domain.DurationPolicy{Mode: durationMode, MaxSpeed: speed}
+		cfg.Window, _ = parseTrimWindow(trimStart, trimEnd)
+		cfg.AutoSegment = autoSegment
+		similarity, _ := strconv.ParseFloat(strings.TrimSpace(keySimilarity), 64)
+		blend, _ := strconv.ParseFloat(strings.TrimSpace(keyBlend), 64)
+		chromaKey, err := parseChromaKey(keyColor, similarity, blend)
+		if err != nil {
+			return WizardConfig{}, err
+		}
+		cfg.ChromaKey = chromaKey
+		cfg.Mode = encodeMode
+		cfg.Quality = quality
+		cfg.CRF, _ = strconv.Atoi(strings.TrimSpace(crf))
+		cfg.Tuning = domain.DefaultVPXTuning
+		cfg.TimeBudget, _ = parseTimeBudget(timeBudget)
+		cfg.Crop = domain.CropOptions{Mode: cropMode}
+		if cropMode == domain.CropManual {
+			cfg.Crop.Rect, _ = domain.ParseCropRect(cropRect)
+		}
+	}
+	if mode != inputModeFile {
+		depth, _ := strconv.Atoi(strings.TrimSpace(maxDepth))
+		cfg.Scan = infra.ScanOptions{
+			Include:       splitPatterns(includePatterns),
+			Exclude:       splitPatterns(excludePatterns),
+			MaxDepth:      depth,
+			IncludeHidden: includeHidden,
+		}
 	}
 	if cfg.OutputDir == "" {
 		cfg.OutputDir = "./output"
 	}
 
-	if cfg.InputIsDir {
-		cfg.InputPath = strings.TrimSpace(dirPath)
-	} else {
-		cfg.InputPath = strings.TrimSpace(filePath)
+	var paths []string
+	switch mode {
+	case inputModeDir:
+		paths = []string{strings.TrimSpace(dirPath)}
+	case inputModeMulti:
+		paths = multiPaths
+	default:
+		paths = []string{strings.TrimSpace(filePath)}
 	}
-	if cfg.InputPath == "" {
+	paths = lo.Compact(paths)
+	if len(paths) == 0 {
 		return WizardConfig{}, fmt.Errorf("input path is required")
 	}
 
+	inputs, err := selection.ResolvePaths(paths)
+	if err != nil {
+		return WizardConfig{}, err
+	}
+	cfg.Inputs = inputs
+
 	return cfg, nil
 }
 
+func validateDepth(value string) error {
+	depth, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || depth < 0 {
+		return fmt.Errorf("enter a non-negative number")
+	}
+	return nil
+}
+
+func validateSpeed(value string) error {
+	speed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || speed < 1 {
+		return fmt.Errorf("enter a number of at least 1")
+	}
+	return nil
+}
+
+func validateTimeBudget(value string) error {
+	if _, err := parseTimeBudget(value); err != nil {
+		return fmt.Errorf("enter a duration such as 90s or 2m")
+	}
+	return nil
+}
+
+func parseTimeBudget(value string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return 0, nil
+	}
+	budget, err := time.ParseDuration(trimmed)
+	if err != nil {
+		return 0, err
+	}
+	if budget < 0 {
+		return 0, fmt.Errorf("time budget must not be negative")
+	}
+	return budget, nil
+}
+
+func validateCRF(value string) error {
+	v, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || v < domain.MinCRF || v > domain.MaxCRF {
+		return fmt.Errorf("enter a number between %d and %d", domain.MinCRF, domain.MaxCRF)
+	}
+	return nil
+}
+
+func validateUnitInterval(value string) error {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v < 0 || v > 1 {
+		return fmt.Errorf("enter a number between 0 and 1")
+	}
+	return nil
+}
+
+func validateKeySimilarity(value string) error {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v <= 0 || v > 1 {
+		return fmt.Errorf("enter a number above 0 and at most 1")
+	}
+	return nil
+}
+
+func validateTimestamp(value string) error {
+	_, err := domain.ParseTimestamp(value)
+	return err
+}
+
+func newDurationDescriber() func(path string) string {
+	cache := make(map[string]string)
+	return func(path string) string {
+		if path == "" {
+			return "Seconds or [hh:]mm:ss."
+		}
+		if description, ok := cache[path]; ok {
+			return description
+		}
+		description := "Seconds or [hh:]mm:ss. Duration unknown."
+		info, err := infra.FFprobeRunner{}.Probe(context.Background(), path)
+		if err == nil && info.DurationSeconds > 0 {
+			description = fmt.Sprintf("Seconds or [hh:]mm:ss. %s is %s long.", filepath.Base(path), domain.FormatSeconds(math.Round(info.DurationSeconds*100)/100))
+		}
+		cache[path] = description
+		return description
+	}
+}
+
+func usesVideoPipeline(t target.TargetType) bool {
+	switch t {
+	case target.TargetVideoSticker, target.TargetVideoEmoji, target.TargetThumbnail:
+		return true
+	default:
+		return false
+	}
+}
+
+func browseOptions(dir string) []huh.Option[string] {
+	entries, err := infra.ListDirEntries(dir)
+	if err != nil {
+		return nil
+	}
+	options := make([]huh.Option[string], 0, len(entries))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, ".") {
+			continue
+		}
+		if e.IsDir {
+			options = append(options, huh.NewOption(e.Name+string(filepath.Separator), e.Path))
+			continue
+		}
+		if lo.Contains(supportedFileTypes, strings.ToLower(filepath.Ext(e.Name))) {
+			options = append(options, huh.NewOption(e.Name, e.Path))
+		}
+	}
+	return options
+}
+
 func ConfirmPlan(accessible bool, title string, summary string) (bool, error) {
 	confirmed := false
 	form := huh.NewForm(
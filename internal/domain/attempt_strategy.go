@@ -0,0 +1,258 @@
+package domain
+
+import "math"
+
+const (
+	MaxBisectIterations = 8
+	bisectTargetRatio   = 0.95
+	bisectCloseEnough   = 0.88
+	bisectMinStepKbps   = 10
+)
+
+type AttemptOutcome struct {
+	Attempt   EncodeAttempt
+	SizeBytes int64
+	Issues    []ValidationIssue
+	Err       error
+}
+
+func (o AttemptOutcome) Passed() bool {
+	return o.Err == nil && len(o.Issues) == 0
+}
+
+func (o AttemptOutcome) onlySizeIssues() bool {
+	for _, issue := range o.Issues {
+		if issue.Code != "size" {
+			return false
+		}
+	}
+	return true
+}
+
+type AttemptStrategy interface {
+	Next() (EncodeAttempt, bool)
+	Observe(outcome AttemptOutcome)
+	Best() (EncodeAttempt, bool)
+}
+
+type LadderStrategy struct {
+	attempts []EncodeAttempt
+	next     int
+	best     EncodeAttempt
+	found    bool
+}
+
+func NewLadderStrategy(attempts []EncodeAttempt) *LadderStrategy {
+	return &LadderStrategy{attempts: attempts}
+}
+
+func (s *LadderStrategy) Next() (EncodeAttempt, bool) {
+	if s.found || s.next >= len(s.attempts) {
+		return EncodeAttempt{}, false
+	}
+	a := s.attempts[s.next]
+	s.next++
+	return a, true
+}
+
+func (s *LadderStrategy) Observe(outcome AttemptOutcome) {
+	if outcome.Passed() {
+		s.best = outcome.Attempt
+		s.found = true
+	}
+}
+
+func (s *LadderStrategy) Best() (EncodeAttempt, bool) {
+	return s.best, s.found
+}
+
+type bisectLevel struct {
+	base      EncodeAttempt
+	floorKbps int
+	ceilKbps  int
+}
+
+type BisectStrategy struct {
+	levels       []bisectLevel
+	ladder       *LadderStrategy
+	maxSizeBytes int64
+	observed     map[EncodeAttempt]struct{}
+	level        int
+	loKbps       int
+	hiKbps       int
+	nextKbps     int
+	iterations   int
+	best         EncodeAttempt
+	bestSize     int64
+	found        bool
+	fallback     bool
+	bound        int
+	done         bool
+}
+
+func NewBisectStrategy(attempts []EncodeAttempt, maxSizeBytes int64) *BisectStrategy {
+	s := &BisectStrategy{
+		ladder:       NewLadderStrategy(attempts),
+		maxSizeBytes: maxSizeBytes,
+		observed:     make(map[EncodeAttempt]struct{}),
+	}
+	index := make(map[[3]int]int)
+	for _, a := range attempts {
+		key := [3]int{a.Width, a.Height, a.FPS}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(s.levels)
+			s.levels = append(s.levels, bisectLevel{base: a, floorKbps: a.BitrateKbps, ceilKbps: a.BitrateKbps})
+			continue
+		}
+		s.levels[i].floorKbps = min(s.levels[i].floorKbps, a.BitrateKbps)
+		s.levels[i].ceilKbps = max(s.levels[i].ceilKbps, a.BitrateKbps)
+	}
+	if maxSizeBytes <= 0 || len(s.levels) == 0 {
+		s.startFallback()
+	}
+	return s
+}
+
+func (s *BisectStrategy) Next() (EncodeAttempt, bool) {
+	if s.fallback {
+		return s.ladderNext()
+	}
+	if s.done {
+		return EncodeAttempt{}, false
+	}
+	if s.iterations >= MaxBisectIterations || s.level >= len(s.levels) {
+		if s.found {
+			s.done = true
+			return EncodeAttempt{}, false
+		}
+		s.startFallback()
+		return s.ladderNext()
+	}
+	a := s.levels[s.level].base
+	if s.nextKbps > 0 {
+		a.BitrateKbps = s.nextKbps
+	}
+	return a, true
+}
+
+func (s *BisectStrategy) startFallback() {
+	s.fallback = true
+	s.bound = s.iterations
+	for _, a := range s.ladder.attempts[s.ladder.next:] {
+		if _, seen := s.observed[a]; !seen {
+			s.bound++
+		}
+	}
+}
+
+func (s *BisectStrategy) Bound() int {
+	if s.fallback {
+		return s.bound
+	}
+	return MaxBisectIterations
+}
+
+func (s *BisectStrategy) ladderNext() (EncodeAttempt, bool) {
+	for {
+		a, ok := s.ladder.Next()
+		if !ok {
+			return EncodeAttempt{}, false
+		}
+		if _, seen := s.observed[a]; !seen {
+			return a, true
+		}
+	}
+}
+
+func (s *BisectStrategy) Observe(outcome AttemptOutcome) {
+	s.observed[outcome.Attempt] = struct{}{}
+	if s.fallback {
+		s.ladder.Observe(outcome)
+		return
+	}
+	s.iterations++
+	if outcome.Err != nil || !outcome.onlySizeIssues() || outcome.SizeBytes <= 0 {
+		if s.found {
+			s.done = true
+			return
+		}
+		s.startFallback()
+		return
+	}
+
+	kbps := outcome.Attempt.BitrateKbps
+	if outcome.Passed() {
+		if !s.found || outcome.SizeBytes > s.bestSize {
+			s.best = outcome.Attempt
+			s.bestSize = outcome.SizeBytes
+			s.found = true
+		}
+		s.loKbps = kbps
+		if float64(outcome.SizeBytes) >= float64(s.maxSizeBytes)*bisectCloseEnough {
+			s.done = true
+			return
+		}
+	} else {
+		s.hiKbps = kbps
+	}
+
+	next := s.estimate(kbps, outcome.SizeBytes)
+	level := s.levels[s.level]
+	if s.hiKbps > 0 && s.hiKbps-s.loKbps <= bisectMinStepKbps {
+		s.finishLevel()
+		return
+	}
+	if next > level.ceilKbps {
+		if s.loKbps >= level.ceilKbps {
+			s.finishLevel()
+			return
+		}
+		next = level.ceilKbps
+	}
+	if next < level.floorKbps {
+		if s.loKbps > 0 || kbps <= level.floorKbps {
+			s.finishLevel()
+			return
+		}
+		next = level.floorKbps
+	}
+	s.nextKbps = next
+}
+
+func (s *BisectStrategy) estimate(kbps int, sizeBytes int64) int {
+	target := float64(s.maxSizeBytes) * bisectTargetRatio
+	next := int(math.Round(float64(kbps) * target / float64(sizeBytes)))
+	if s.hiKbps > 0 && next >= s.hiKbps {
+		next = (s.loKbps + s.hiKbps) / 2
+	}
+	if next <= s.loKbps {
+		if s.hiKbps > 0 {
+			next = (s.loKbps + s.hiKbps) / 2
+		} else {
+			next = s.loKbps + s.loKbps/4
+		}
+	}
+	return next
+}
+
+func (s *BisectStrategy) finishLevel() {
+	if s.found {
+		s.done = true
+		return
+	}
+	s.level++
+	s.loKbps = 0
+	s.hiKbps = 0
+	s.nextKbps = 0
+	if s.level < len(s.levels) {
+		s.nextKbps = s.levels[s.level].floorKbps
+	}
+}
+
+func (s *BisectStrategy) Best() (EncodeAttempt, bool) {
+	if s.fallback && !s.found {
+		return s.ladder.Best()
+	}
+	return s.best, s.found
+}
@@ -0,0 +1,138 @@
+package domain
+
+import "testing"
+
+func simulateSize(a EncodeAttempt) int64 {
+	scale := float64(a.Width*a.Height) / float64(512*512)
+	return int64(float64(a.BitrateKbps) * 1000 / 8 * 3 * (0.6 + 0.4*scale))
+}
+
+func runStrategy(t *testing.T, s AttemptStrategy, size func(EncodeAttempt) int64, limit int64) (int, EncodeAttempt) {
+	t.Helper()
+	encodes := 0
+	for {
+		a, ok := s.Next()
+		if !ok {
+			break
+		}
+		encodes++
+		if encodes > 100 {
+			t.Fatalf("strategy did not terminate")
+		}
+		bytes := size(a)
+		outcome := AttemptOutcome{Attempt: a, SizeBytes: bytes}
+		if bytes > limit {
+			outcome.Issues = []ValidationIssue{{Code: "size", Message: "size exceeds limit"}}
+		}
+		s.Observe(outcome)
+	}
+	best, ok := s.Best()
+	if !ok {
+		t.Fatalf("expected a passing attempt after %d encodes", encodes)
+	}
+	return encodes, best
+}
+
+func TestBisectStrategyConvergesToBudget(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	limit := TelegramVideoSticker.MaxSizeBytes
+	overshoot := func(a EncodeAttempt) int64 { return simulateSize(a) * 3 / 2 }
+
+	encodes, best := runStrategy(t, NewBisectStrategy(attempts, limit), overshoot, limit)
+	if encodes > 4 {
+		t.Fatalf("expected few encodes, got %d", encodes)
+	}
+	if size := overshoot(best); size > limit || float64(size) < float64(limit)*0.85 {
+		t.Fatalf("best attempt is far from budget: %d of %d", size, limit)
+	}
+	if best.Width != 512 {
+		t.Fatalf("expected full scale, got %+v", best)
+	}
+}
+
+func TestBisectStrategyDropsScaleAtFloor(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	limit := TelegramVideoSticker.MaxSizeBytes
+	heavy := func(a EncodeAttempt) int64 {
+		if a.Width == 512 {
+			return limit * 2
+		}
+		return simulateSize(a)
+	}
+
+	_, best := runStrategy(t, NewBisectStrategy(attempts, limit), heavy, limit)
+	if best.Width >= 512 {
+		t.Fatalf("expected reduced scale, got %+v", best)
+	}
+}
+
+func TestBisectStrategyFallsBackToLadder(t *testing.T) {
+	attempts := []EncodeAttempt{
+		{Width: 512, Height: 512, BitrateKbps: 600},
+		{Width: 512, Height: 512, BitrateKbps: 400},
+	}
+	s := NewBisectStrategy(attempts, 256*1024)
+	if s.Bound() != MaxBisectIterations {
+		t.Fatalf("unexpected bisect bound: %d", s.Bound())
+	}
+	a, _ := s.Next()
+	s.Observe(AttemptOutcome{Attempt: a, Issues: []ValidationIssue{{Code: "codec"}}})
+
+	next, ok := s.Next()
+	if !ok || next != attempts[1] {
+		t.Fatalf("expected ladder to skip the observed attempt, got %+v ok=%v", next, ok)
+	}
+	if s.Bound() != 2 {
+		t.Fatalf("expected bound of one bisect encode plus one ladder attempt, got %d", s.Bound())
+	}
+	s.Observe(AttemptOutcome{Attempt: next, SizeBytes: 100})
+	if best, ok := s.Best(); !ok || best != attempts[1] {
+		t.Fatalf("unexpected best: %+v ok=%v", best, ok)
+	}
+	if _, ok := s.Next(); ok {
+		t.Fatalf("expected ladder to stop after success")
+	}
+}
+
+func TestBisectStrategyStopsAtCeilingBitrate(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 3}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	tiny := func(EncodeAttempt) int64 { return 2 }
+	encodes, best := runStrategy(t, NewBisectStrategy(attempts, TelegramVideoSticker.MaxSizeBytes), tiny, TelegramVideoSticker.MaxSizeBytes)
+	if encodes != 1 || best != attempts[0] {
+		t.Fatalf("expected the base attempt after one encode, got %d encodes and %+v", encodes, best)
+	}
+}
+
+func TestBisectStrategyGrowsPastReorderedBase(t *testing.T) {
+	info := MediaInfo{Width: 1920, Height: 1080, FPS: 30, DurationSeconds: 3, InputSizeBytes: 5 << 20, BitrateBps: 14_000_000}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	limit := TelegramVideoSticker.MaxSizeBytes
+	ceil := 0
+	for _, a := range attempts {
+		if a.Width == attempts[0].Width && a.Height == attempts[0].Height && a.FPS == attempts[0].FPS {
+			ceil = max(ceil, a.BitrateKbps)
+		}
+	}
+	if attempts[0].BitrateKbps >= ceil {
+		t.Fatalf("expected a reordered ladder, got base %d and ceiling %d", attempts[0].BitrateKbps, ceil)
+	}
+	_, best := runStrategy(t, NewBisectStrategy(attempts, limit), simulateSize, limit)
+	if size := simulateSize(best); float64(size) < float64(limit)*bisectCloseEnough && best.BitrateKbps < ceil {
+		t.Fatalf("stopped at %dk (%d of %d bytes) below the %dk ceiling", best.BitrateKbps, size, limit, ceil)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultKeySimilarity = 0.1
+	DefaultKeyBlend      = 0.0
+)
+
+var keyColorNames = map[string]string{
+	"green": "0x00FF00",
+	"blue":  "0x0000FF",
+	"black": "0x000000",
+	"white": "0xFFFFFF",
+}
+
+type ChromaKey struct {
+	Color      string
+	Similarity float64
+	Blend      float64
+}
+
+func (k ChromaKey) Enabled() bool {
+	return k.Color != ""
+}
+
+func (k ChromaKey) Validate() error {
+	if !k.Enabled() {
+		return nil
+	}
+	if k.Similarity <= 0 || k.Similarity > 1 {
+		return fmt.Errorf("key similarity must be in (0, 1]")
+	}
+	if k.Blend < 0 || k.Blend > 1 {
+		return fmt.Errorf("key blend must be in [0, 1]")
+	}
+	return nil
+}
+
+func ParseKeyColor(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+	if hex, ok := keyColorNames[value]; ok {
+		return hex, nil
+	}
+	hex := strings.TrimPrefix(strings.TrimPrefix(value, "#"), "0x")
+	if len(hex) != 6 {
+		return "", fmt.Errorf("invalid key color: %s", value)
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "", fmt.Errorf("invalid key color: %s", value)
+	}
+	return "0x" + strings.ToUpper(hex), nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "testing"
+
+func TestParseKeyColor(t *testing.T) {
+	cases := map[string]string{
+		"":         "",
+		"green":    "0x00FF00",
+		"#00b140":  "0x00B140",
+		"0x1a2B3c": "0x1A2B3C",
+	}
+	for input, want := range cases {
+		got, err := ParseKeyColor(input)
+		if err != nil {
+			t.Fatalf("unexpected err for %q: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("%q: got %s want %s", input, got, want)
+		}
+	}
+	for _, input := range []string{"chartreuse", "#12345", "0xGGGGGG"} {
+		if _, err := ParseKeyColor(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestChromaKeyValidate(t *testing.T) {
+	if err := (ChromaKey{}).Validate(); err != nil {
+		t.Fatalf("disabled key should be valid: %v", err)
+	}
+	if err := (ChromaKey{Color: "0x00FF00", Similarity: 0.2, Blend: 0.1}).Validate(); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := (ChromaKey{Color: "0x00FF00", Similarity: 0}).Validate(); err == nil {
+		t.Fatalf("expected similarity error")
+	}
+	if err := (ChromaKey{Color: "0x00FF00", Similarity: 0.2, Blend: 2}).Validate(); err == nil {
+		t.Fatalf("expected blend error")
+	}
+}
@@ -2,17 +2,6 @@ package domain
 
 import "fmt"
 
-const (
-	MaxStickerSide            = 512
-	MaxStickerFPS             = 30
-	MaxStickerDurationSeconds = 3
-	MaxStickerSizeBytes       = 256 * 1024
-	DefaultImageFPS           = 30
-	DefaultImageDuration      = 3
-	StaticStickerSide         = 512
-	EmojiSide                 = 100
-)
-
 type Size struct {
 	Width  int
 	Height int
@@ -14,7 +14,7 @@ func TestScaleToFit(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		got, err := ScaleToFit(c.src, MaxStickerSide)
+		got, err := ScaleToFit(c.src, TelegramVideoSticker.MaxSide)
 		if err != nil {
 			t.Fatalf("%s: unexpected err: %v", c.name, err)
 		}
@@ -25,7 +25,7 @@ func TestScaleToFit(t *testing.T) {
 }
 
 func TestScaleToFitInvalid(t *testing.T) {
-	_, err := ScaleToFit(Size{Width: 0, Height: 10}, MaxStickerSide)
+	_, err := ScaleToFit(Size{Width: 0, Height: 10}, TelegramVideoSticker.MaxSide)
 	if err == nil {
 		t.Fatal("expected error")
 	}
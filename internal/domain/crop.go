@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type CropMode string
+
+const (
+	CropNone         CropMode = "none"
+	CropCenterSquare CropMode = "square"
+	CropAuto         CropMode = "auto"
+	CropManual       CropMode = "manual"
+)
+
+type CropRect struct {
+	Width  int
+	Height int
+	X      int
+	Y      int
+}
+
+type CropOptions struct {
+	Mode CropMode
+	Rect CropRect
+}
+
+func (r CropRect) IsZero() bool {
+	return r.Width == 0 && r.Height == 0
+}
+
+func (r CropRect) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", r.Width, r.Height, r.X, r.Y)
+}
+
+func ParseCrop(value string) (CropOptions, error) {
+	value = strings.TrimSpace(value)
+	switch CropMode(value) {
+	case "", CropNone:
+		return CropOptions{Mode: CropNone}, nil
+	case CropCenterSquare, CropAuto:
+		return CropOptions{Mode: CropMode(value)}, nil
+	}
+	rect, err := ParseCropRect(value)
+	if err != nil {
+		return CropOptions{}, err
+	}
+	return CropOptions{Mode: CropManual, Rect: rect}, nil
+}
+
+func ParseCropRect(value string) (CropRect, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 4 {
+		return CropRect{}, fmt.Errorf("invalid crop %q: expected none, square, auto or W:H:X:Y", value)
+	}
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return CropRect{}, fmt.Errorf("invalid crop %q: expected non-negative integers", value)
+		}
+		nums[i] = n
+	}
+	rect := CropRect{Width: nums[0], Height: nums[1], X: nums[2], Y: nums[3]}
+	if rect.Width == 0 || rect.Height == 0 {
+		return CropRect{}, fmt.Errorf("invalid crop %q: width and height must be positive", value)
+	}
+	return rect, nil
+}
+
+func (c CropOptions) Resolve(width int, height int, detected CropRect) (CropRect, error) {
+	switch c.Mode {
+	case CropCenterSquare:
+		side := min(width, height)
+		if side <= 0 || width == height {
+			return CropRect{}, nil
+		}
+		return CropRect{Width: side, Height: side, X: (width - side) / 2, Y: (height - side) / 2}, nil
+	case CropAuto:
+		if detected.IsZero() || (detected.Width == width && detected.Height == height) {
+			return CropRect{}, nil
+		}
+		return detected, nil
+	case CropManual:
+		r := c.Rect
+		if width > 0 && height > 0 && (r.X+r.Width > width || r.Y+r.Height > height) {
+			return CropRect{}, fmt.Errorf("crop %s is outside the %dx%d frame", r, width, height)
+		}
+		return r, nil
+	default:
+		return CropRect{}, nil
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "testing"
+
+func TestParseCrop(t *testing.T) {
+	cases := map[string]CropOptions{
+		"":             {Mode: CropNone},
+		"square":       {Mode: CropCenterSquare},
+		"auto":         {Mode: CropAuto},
+		"640:360:0:60": {Mode: CropManual, Rect: CropRect{Width: 640, Height: 360, Y: 60}},
+	}
+	for input, want := range cases {
+		got, err := ParseCrop(input)
+		if err != nil {
+			t.Fatalf("unexpected err for %q: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("%q: got %+v want %+v", input, got, want)
+		}
+	}
+	for _, input := range []string{"wide", "1:2:3", "0:10:0:0", "-5:10:0:0"} {
+		if _, err := ParseCrop(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestCropResolve(t *testing.T) {
+	square, err := CropOptions{Mode: CropCenterSquare}.Resolve(1920, 1080, CropRect{})
+	if err != nil || square != (CropRect{Width: 1080, Height: 1080, X: 420}) {
+		t.Fatalf("unexpected square crop: %+v err=%v", square, err)
+	}
+
+	detected := CropRect{Width: 1920, Height: 800, Y: 140}
+	auto, err := CropOptions{Mode: CropAuto}.Resolve(1920, 1080, detected)
+	if err != nil || auto != detected {
+		t.Fatalf("unexpected auto crop: %+v err=%v", auto, err)
+	}
+	full, _ := CropOptions{Mode: CropAuto}.Resolve(1920, 1080, CropRect{Width: 1920, Height: 1080})
+	if !full.IsZero() {
+		t.Fatalf("expected no crop for full frame, got %+v", full)
+	}
+
+	if _, err := (CropOptions{Mode: CropManual, Rect: CropRect{Width: 640, Height: 640, X: 1500}}).Resolve(1920, 1080, CropRect{}); err == nil {
+		t.Fatalf("expected out of bounds error")
+	}
+}
@@ -0,0 +1,14 @@
+package domain
+
+type EncodeError struct {
+	Err     error
+	LogPath string
+}
+
+func (e *EncodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
@@ -1,5 +1,116 @@
 package domain
 
+import (
+	"fmt"
+	"math"
+)
+
+type DurationMode string
+
+const (
+	DurationTrim          DurationMode = "trim"
+	DurationSpeedUp       DurationMode = "speedup"
+	DurationSpeedUpCapped DurationMode = "speedup_capped"
+)
+
+const DefaultMaxSpeed = 2.0
+
+type EncodeMode string
+
+const (
+	EncodeSinglePass         EncodeMode = "single"
+	EncodeTwoPass            EncodeMode = "two_pass"
+	EncodeConstrainedQuality EncodeMode = "cq"
+)
+
+const (
+	DefaultCRF = 32
+	MinCRF     = 1
+	MaxCRF     = 63
+)
+
+type VPXTuning struct {
+	Deadline    string
+	CPUUsed     int
+	RowMT       bool
+	TileColumns int
+}
+
+var DefaultVPXTuning = VPXTuning{Deadline: "good", CPUUsed: 1, RowMT: true, TileColumns: 1}
+
+type DurationPolicy struct {
+	Mode     DurationMode
+	MaxSpeed float64
+}
+
 type EncodeOptions struct {
 	TrimSeconds int
+	Profile     Profile
+	Duration    DurationPolicy
+	Window      TrimWindow
+	ChromaKey   ChromaKey
+	Crop        CropRect
+	Mode        EncodeMode
+	CRF         int
+	Tuning      VPXTuning
+	OnProgress  func(EncodeProgress)
+}
+
+func ParseDurationMode(value string) (DurationMode, error) {
+	switch DurationMode(value) {
+	case DurationTrim, DurationSpeedUp, DurationSpeedUpCapped:
+		return DurationMode(value), nil
+	default:
+		return "", fmt.Errorf("unknown duration mode: %s", value)
+	}
+}
+
+func ParseEncodeMode(value string) (EncodeMode, error) {
+	switch EncodeMode(value) {
+	case EncodeSinglePass, EncodeTwoPass, EncodeConstrainedQuality:
+		return EncodeMode(value), nil
+	default:
+		return "", fmt.Errorf("unknown encode mode: %s", value)
+	}
+}
+
+func (t VPXTuning) IsZero() bool {
+	return t == VPXTuning{}
+}
+
+func (t VPXTuning) Validate() error {
+	switch t.Deadline {
+	case "good", "best", "realtime":
+	default:
+		return fmt.Errorf("unknown deadline: %s", t.Deadline)
+	}
+	if t.CPUUsed < -8 || t.CPUUsed > 8 {
+		return fmt.Errorf("cpu-used must be between -8 and 8")
+	}
+	if t.TileColumns < 0 || t.TileColumns > 6 {
+		return fmt.Errorf("tile-columns must be between 0 and 6")
+	}
+	return nil
+}
+
+func (p DurationPolicy) Speed(sourceSeconds float64, maxSeconds int) float64 {
+	if sourceSeconds <= float64(maxSeconds) || maxSeconds <= 0 {
+		return 1
+	}
+	fit := sourceSeconds / float64(maxSeconds)
+	switch p.Mode {
+	case DurationSpeedUp:
+		return fit
+	case DurationSpeedUpCapped:
+		maxSpeed := p.MaxSpeed
+		if maxSpeed <= 0 {
+			maxSpeed = DefaultMaxSpeed
+		}
+		if maxSpeed < 1 {
+			return 1
+		}
+		return math.Min(fit, maxSpeed)
+	default:
+		return 1
+	}
 }
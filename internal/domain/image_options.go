@@ -1,6 +1,17 @@
 package domain
 
 type ImageEncodeOptions struct {
-	TargetSide  int
-	PadToSquare bool
+	Profile Profile
+	Quality int
+}
+
+func BuildImageAttempts(profile Profile) []ImageEncodeOptions {
+	attempts := []ImageEncodeOptions{{Profile: profile}}
+	if profile.Fallback == nil {
+		return attempts
+	}
+	for _, q := range []int{90, 75, 60, 45} {
+		attempts = append(attempts, ImageEncodeOptions{Profile: *profile.Fallback, Quality: q})
+	}
+	return attempts
 }
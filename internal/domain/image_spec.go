@@ -1,38 +1,25 @@
 package domain
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type ImageInfo struct {
-	Width  int
-	Height int
-	Format string
+	Width     int
+	Height    int
+	Format    string
+	SizeBytes int64
 }
 
-func ValidateStaticStickerImage(info ImageInfo) []ValidationIssue {
+func ValidateImage(info ImageInfo, profile Profile) []ValidationIssue {
 	issues := make([]ValidationIssue, 0)
-	if !isPNG(info.Format) {
-		issues = append(issues, ValidationIssue{Code: "format", Message: "format is not png"})
+	if strings.ToLower(info.Format) != profile.Container {
+		issues = append(issues, ValidationIssue{Code: "format", Message: fmt.Sprintf("format is not %s", profile.Container)})
 	}
-	if info.Width != StaticStickerSide && info.Height != StaticStickerSide {
-		issues = append(issues, ValidationIssue{Code: "size", Message: "one side must be 512"})
-	}
-	if info.Width > StaticStickerSide || info.Height > StaticStickerSide {
-		issues = append(issues, ValidationIssue{Code: "size", Message: "dimension exceeds 512"})
+	if profile.MaxSizeBytes > 0 && info.SizeBytes > profile.MaxSizeBytes {
+		issues = append(issues, ValidationIssue{Code: "size", Message: "size exceeds limit"})
 	}
+	issues = append(issues, validateDimensions(info.Width, info.Height, profile)...)
 	return issues
 }
-
-func ValidateEmojiImage(info ImageInfo) []ValidationIssue {
-	issues := make([]ValidationIssue, 0)
-	if !isPNG(info.Format) {
-		issues = append(issues, ValidationIssue{Code: "format", Message: "format is not png"})
-	}
-	if info.Width != EmojiSide || info.Height != EmojiSide {
-		issues = append(issues, ValidationIssue{Code: "size", Message: "dimension must be 100x100"})
-	}
-	return issues
-}
-
-func isPNG(format string) bool {
-	return strings.ToLower(format) == "png"
-}
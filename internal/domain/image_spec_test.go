@@ -3,30 +3,58 @@ package domain
 import "testing"
 
 func TestValidateStaticStickerImage(t *testing.T) {
-	ok := ValidateStaticStickerImage(ImageInfo{Width: 512, Height: 300, Format: "png"})
+	ok := ValidateImage(ImageInfo{Width: 512, Height: 300, Format: "png"}, TelegramStaticSticker)
 	if len(ok) != 0 {
 		t.Fatalf("expected no issues")
 	}
 
-	issues := ValidateStaticStickerImage(ImageInfo{Width: 300, Height: 300, Format: "png"})
+	issues := ValidateImage(ImageInfo{Width: 300, Height: 300, Format: "png"}, TelegramStaticSticker)
 	if len(issues) == 0 {
 		t.Fatalf("expected issues")
 	}
 
-	formatIssues := ValidateStaticStickerImage(ImageInfo{Width: 512, Height: 512, Format: "WEBP"})
+	formatIssues := ValidateImage(ImageInfo{Width: 512, Height: 512, Format: "WEBP"}, TelegramStaticSticker)
 	if len(formatIssues) == 0 {
 		t.Fatalf("expected format issue")
 	}
 }
 
 func TestValidateEmojiImage(t *testing.T) {
-	ok := ValidateEmojiImage(ImageInfo{Width: 100, Height: 100, Format: "PNG"})
+	ok := ValidateImage(ImageInfo{Width: 100, Height: 100, Format: "PNG"}, TelegramEmoji)
 	if len(ok) != 0 {
 		t.Fatalf("expected no issues")
 	}
 
-	issues := ValidateEmojiImage(ImageInfo{Width: 100, Height: 90, Format: "png"})
+	issues := ValidateImage(ImageInfo{Width: 100, Height: 90, Format: "png"}, TelegramEmoji)
 	if len(issues) == 0 {
 		t.Fatalf("expected issues")
 	}
 }
+
+func TestValidateThumbnailImage(t *testing.T) {
+	ok := ValidateImage(ImageInfo{Width: 100, Height: 100, Format: "webp", SizeBytes: 20 * 1024}, TelegramThumbnailWebP)
+	if len(ok) != 0 {
+		t.Fatalf("unexpected issues: %v", ok)
+	}
+
+	issues := ValidateImage(ImageInfo{Width: 100, Height: 100, Format: "png", SizeBytes: 40 * 1024}, TelegramThumbnail)
+	if len(issues) != 1 || issues[0].Code != "size" {
+		t.Fatalf("expected size issue, got: %v", issues)
+	}
+}
+
+func TestBuildImageAttempts(t *testing.T) {
+	if attempts := BuildImageAttempts(TelegramStaticSticker); len(attempts) != 1 {
+		t.Fatalf("expected single attempt, got: %+v", attempts)
+	}
+
+	attempts := BuildImageAttempts(TelegramThumbnail)
+	if len(attempts) < 2 || attempts[0].Profile.Container != "png" {
+		t.Fatalf("unexpected attempts: %+v", attempts)
+	}
+	for _, a := range attempts[1:] {
+		if a.Profile.Container != "webp" || a.Quality <= 0 {
+			t.Fatalf("unexpected fallback attempt: %+v", a)
+		}
+	}
+}
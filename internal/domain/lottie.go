@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+type LottieInfo struct {
+	Width     int
+	Height    int
+	FrameRate float64
+	InPoint   float64
+	OutPoint  float64
+}
+
+func (l LottieInfo) DurationSeconds() float64 {
+	if l.FrameRate <= 0 {
+		return 0
+	}
+	return (l.OutPoint - l.InPoint) / l.FrameRate
+}
+
+type LottieDocument struct {
+	Info LottieInfo
+	root map[string]any
+}
+
+var lottieUnusedKeys = map[string]struct{}{"nm": {}, "mn": {}, "cl": {}, "ln": {}, "ix": {}, "meta": {}}
+
+func ParseLottie(data []byte) (LottieDocument, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return LottieDocument{}, fmt.Errorf("invalid lottie json: %w", err)
+	}
+	if _, ok := root["layers"].([]any); !ok {
+		return LottieDocument{}, fmt.Errorf("invalid lottie json: missing layers")
+	}
+	info := LottieInfo{
+		Width:     int(numberField(root, "w")),
+		Height:    int(numberField(root, "h")),
+		FrameRate: numberField(root, "fr"),
+		InPoint:   numberField(root, "ip"),
+		OutPoint:  numberField(root, "op"),
+	}
+	return LottieDocument{Info: info, root: root}, nil
+}
+
+func ValidateLottie(doc LottieDocument, profile Profile) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	info := doc.Info
+	if info.Width != profile.MaxSide || info.Height != profile.MaxSide {
+		issues = append(issues, ValidationIssue{Code: "canvas", Message: fmt.Sprintf("canvas must be %dx%d", profile.MaxSide, profile.MaxSide)})
+	}
+	if info.FrameRate != float64(profile.MaxFPS) {
+		issues = append(issues, ValidationIssue{Code: "fps", Message: fmt.Sprintf("frame rate must be %d", profile.MaxFPS)})
+	}
+	if info.DurationSeconds() <= 0 || info.DurationSeconds() > float64(profile.MaxDurationSeconds) {
+		issues = append(issues, ValidationIssue{Code: "duration", Message: fmt.Sprintf("duration must be at most %d seconds", profile.MaxDurationSeconds)})
+	}
+
+	found := make(map[string]bool)
+	if numberField(doc.root, "ddd") == 1 {
+		found["3d"] = true
+	}
+	if assets, ok := doc.root["assets"].([]any); ok {
+		for _, a := range assets {
+			asset, ok := a.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, isImage := asset["p"]; isImage {
+				found["image"] = true
+			}
+			scanLottieLayers(asset["layers"], found)
+		}
+	}
+	scanLottieLayers(doc.root["layers"], found)
+	scanLottieExpressions(doc.root, found)
+
+	if found["image"] {
+		issues = append(issues, ValidationIssue{Code: "image", Message: "embedded images are not allowed"})
+	}
+	if found["expression"] {
+		issues = append(issues, ValidationIssue{Code: "expression", Message: "expressions are not allowed"})
+	}
+	if found["3d"] {
+		issues = append(issues, ValidationIssue{Code: "3d", Message: "3D layers are not allowed"})
+	}
+	return issues
+}
+
+func ValidateTGSSize(sizeBytes int64, profile Profile) []ValidationIssue {
+	if profile.MaxSizeBytes > 0 && sizeBytes > profile.MaxSizeBytes {
+		return []ValidationIssue{{Code: "size", Message: "size exceeds limit"}}
+	}
+	return nil
+}
+
+func MinifyLottie(doc LottieDocument, precision int) ([]byte, error) {
+	scale := math.Pow(10, float64(precision))
+	return json.Marshal(minifyLottieValue(doc.root, scale))
+}
+
+func scanLottieLayers(value any, found map[string]bool) {
+	layers, ok := value.([]any)
+	if !ok {
+		return
+	}
+	for _, l := range layers {
+		layer, ok := l.(map[string]any)
+		if !ok {
+			continue
+		}
+		if numberField(layer, "ty") == 2 {
+			found["image"] = true
+		}
+		if numberField(layer, "ddd") == 1 {
+			found["3d"] = true
+		}
+	}
+}
+
+func scanLottieExpressions(value any, found map[string]bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if _, ok := v["x"].(string); ok {
+			found["expression"] = true
+		}
+		for _, child := range v {
+			scanLottieExpressions(child, found)
+		}
+	case []any:
+		for _, child := range v {
+			scanLottieExpressions(child, found)
+		}
+	}
+}
+
+func minifyLottieValue(value any, scale float64) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, child := range v {
+			if _, unused := lottieUnusedKeys[key]; unused {
+				continue
+			}
+			out[key] = minifyLottieValue(child, scale)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, child := range v {
+			out[i] = minifyLottieValue(child, scale)
+		}
+		return out
+	case float64:
+		return math.Round(v*scale) / scale
+	default:
+		return v
+	}
+}
+
+func numberField(obj map[string]any, key string) float64 {
+	v, _ := obj[key].(float64)
+	return v
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const validLottie = `{"v":"5.7.4","nm":"wave","fr":60,"ip":0,"op":180,"w":512,"h":512,"ddd":0,"meta":{"g":"bodymovin"},
+"assets":[{"id":"comp_0","nm":"inner","layers":[{"ty":4,"nm":"shape","ddd":0,"ks":{"o":{"a":0,"k":100,"ix":11}}}]}],
+"layers":[{"ty":0,"nm":"pre","refId":"comp_0","ddd":0,"ks":{"p":{"a":0,"k":[256.123456,256.987654,0],"ix":2}}}]}`
+
+func TestParseLottie(t *testing.T) {
+	doc, err := ParseLottie([]byte(validLottie))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if doc.Info.Width != 512 || doc.Info.Height != 512 || doc.Info.FrameRate != 60 {
+		t.Fatalf("unexpected info: %+v", doc.Info)
+	}
+	if doc.Info.DurationSeconds() != 3 {
+		t.Fatalf("unexpected duration: %v", doc.Info.DurationSeconds())
+	}
+
+	if _, err := ParseLottie([]byte(`{"w":512}`)); err == nil {
+		t.Fatal("expected error for missing layers")
+	}
+}
+
+func TestValidateLottie(t *testing.T) {
+	doc, err := ParseLottie([]byte(validLottie))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if issues := ValidateLottie(doc, TelegramAnimatedSticker); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+
+	invalid := `{"fr":30,"ip":0,"op":120,"w":400,"h":400,
+"assets":[{"id":"image_0","w":10,"h":10,"u":"images/","p":"img.png"}],
+"layers":[{"ty":2,"refId":"image_0"},{"ty":4,"ddd":1,"ks":{"r":{"a":0,"k":0,"x":"time * 10"}}}]}`
+	doc, err = ParseLottie([]byte(invalid))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	codes := make(map[string]bool)
+	for _, issue := range ValidateLottie(doc, TelegramAnimatedSticker) {
+		codes[issue.Code] = true
+	}
+	for _, code := range []string{"canvas", "fps", "duration", "image", "expression", "3d"} {
+		if !codes[code] {
+			t.Fatalf("missing %s issue: %v", code, codes)
+		}
+	}
+}
+
+func TestMinifyLottie(t *testing.T) {
+	doc, err := ParseLottie([]byte(validLottie))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	data, err := MinifyLottie(doc, 2)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	text := string(data)
+	for _, key := range []string{`"nm"`, `"ix"`, `"meta"`} {
+		if strings.Contains(text, key) {
+			t.Fatalf("expected %s to be stripped: %s", key, text)
+		}
+	}
+	if !strings.Contains(text, "256.12") || strings.Contains(text, "256.123") {
+		t.Fatalf("expected rounded numbers: %s", text)
+	}
+
+	var roundTrip map[string]any
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatalf("minified json invalid: %v", err)
+	}
+}
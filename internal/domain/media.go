@@ -9,9 +9,11 @@ import (
 type InputKind string
 
 const (
-	InputKindVideo InputKind = "video"
-	InputKindImage InputKind = "image"
-	InputKindGIF   InputKind = "gif"
+	InputKindVideo         InputKind = "video"
+	InputKindImage         InputKind = "image"
+	InputKindGIF           InputKind = "gif"
+	InputKindAnimatedImage InputKind = "animated_image"
+	InputKindLottie        InputKind = "lottie"
 )
 
 type MediaInfo struct {
@@ -24,14 +26,29 @@ type MediaInfo struct {
 	CodecName       string
 	BitrateBps      int64
 	InputSizeBytes  int64
+	PixelFormat     string
+	HasAlpha        bool
 }
 
 var (
-	videoExts = map[string]struct{}{".mp4": {}, ".mov": {}, ".webm": {}, ".mkv": {}, ".avi": {}}
-	imageExts = map[string]struct{}{".png": {}, ".jpg": {}, ".jpeg": {}, ".webp": {}}
-	gifExts   = map[string]struct{}{".gif": {}}
+	videoExts  = map[string]struct{}{".mp4": {}, ".mov": {}, ".webm": {}, ".mkv": {}, ".avi": {}}
+	imageExts  = map[string]struct{}{".png": {}, ".jpg": {}, ".jpeg": {}, ".webp": {}}
+	gifExts    = map[string]struct{}{".gif": {}}
+	lottieExts = map[string]struct{}{".json": {}, ".tgs": {}}
 )
 
+func PixelFormatHasAlpha(pixFmt string) bool {
+	pixFmt = strings.ToLower(pixFmt)
+	switch {
+	case strings.HasPrefix(pixFmt, "yuva"), strings.HasPrefix(pixFmt, "gbrap"), strings.HasPrefix(pixFmt, "ya"):
+		return true
+	case strings.Contains(pixFmt, "rgba"), strings.Contains(pixFmt, "bgra"), strings.Contains(pixFmt, "argb"), strings.Contains(pixFmt, "abgr"):
+		return true
+	default:
+		return false
+	}
+}
+
 func DetectInputKind(path string) (InputKind, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 	if _, ok := gifExts[ext]; ok {
@@ -43,5 +60,12 @@ func DetectInputKind(path string) (InputKind, error) {
 	if _, ok := videoExts[ext]; ok {
 		return InputKindVideo, nil
 	}
+	if _, ok := lottieExts[ext]; ok {
+		return InputKindLottie, nil
+	}
 	return "", fmt.Errorf("unsupported input: %s", path)
 }
+
+func IsAnimatedKind(kind InputKind) bool {
+	return kind == InputKindGIF || kind == InputKindAnimatedImage
+}
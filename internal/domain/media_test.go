@@ -29,3 +29,22 @@ func TestDetectInputKindUnsupported(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestPixelFormatHasAlpha(t *testing.T) {
+	cases := map[string]bool{
+		"yuv420p":     false,
+		"yuva420p":    true,
+		"rgba":        true,
+		"bgra":        true,
+		"argb":        true,
+		"gbrap10le":   true,
+		"pal8":        false,
+		"rgb24":       false,
+		"yuv444p12le": false,
+	}
+	for pixFmt, want := range cases {
+		if got := PixelFormatHasAlpha(pixFmt); got != want {
+			t.Fatalf("%s: got %v want %v", pixFmt, got, want)
+		}
+	}
+}
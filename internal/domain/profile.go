@@ -0,0 +1,101 @@
+package domain
+
+type Profile struct {
+	Name               string
+	MaxSide            int
+	Square             bool
+	MaxFPS             int
+	MaxDurationSeconds int
+	MaxSizeBytes       int64
+	Codec              string
+	Encoder            string
+	Container          string
+	PixelFormat        string
+	Fallback           *Profile
+}
+
+var (
+	TelegramVideoSticker = Profile{
+		Name:               "telegram_video_sticker",
+		MaxSide:            512,
+		MaxFPS:             30,
+		MaxDurationSeconds: 3,
+		MaxSizeBytes:       256 * 1024,
+		Codec:              "vp9",
+		Encoder:            "libvpx-vp9",
+		Container:          "webm",
+		PixelFormat:        "yuv420p",
+	}
+	TelegramVideoEmoji = Profile{
+		Name:               "telegram_video_emoji",
+		MaxSide:            100,
+		Square:             true,
+		MaxFPS:             30,
+		MaxDurationSeconds: 3,
+		MaxSizeBytes:       64 * 1024,
+		Codec:              "vp9",
+		Encoder:            "libvpx-vp9",
+		Container:          "webm",
+		PixelFormat:        "yuv420p",
+	}
+	TelegramAnimatedSticker = Profile{
+		Name:               "telegram_animated_sticker",
+		MaxSide:            512,
+		Square:             true,
+		MaxFPS:             60,
+		MaxDurationSeconds: 3,
+		MaxSizeBytes:       64 * 1024,
+		Codec:              "lottie",
+		Container:          "tgs",
+	}
+	TelegramStaticSticker = Profile{
+		Name:        "telegram_static_sticker",
+		MaxSide:     512,
+		Codec:       "png",
+		Encoder:     "png",
+		Container:   "png",
+		PixelFormat: "rgba",
+	}
+	TelegramThumbnail = Profile{
+		Name:         "telegram_thumbnail",
+		MaxSide:      100,
+		Square:       true,
+		MaxSizeBytes: 32 * 1024,
+		Codec:        "png",
+		Encoder:      "png",
+		Container:    "png",
+		PixelFormat:  "rgba",
+		Fallback:     &TelegramThumbnailWebP,
+	}
+	TelegramThumbnailWebP = Profile{
+		Name:         "telegram_thumbnail_webp",
+		MaxSide:      100,
+		Square:       true,
+		MaxSizeBytes: 32 * 1024,
+		Codec:        "webp",
+		Encoder:      "libwebp",
+		Container:    "webp",
+		PixelFormat:  "yuva420p",
+	}
+	TelegramVideoThumbnail = Profile{
+		Name:               "telegram_video_thumbnail",
+		MaxSide:            100,
+		Square:             true,
+		MaxFPS:             30,
+		MaxDurationSeconds: 3,
+		MaxSizeBytes:       32 * 1024,
+		Codec:              "vp9",
+		Encoder:            "libvpx-vp9",
+		Container:          "webm",
+		PixelFormat:        "yuv420p",
+	}
+	TelegramEmoji = Profile{
+		Name:        "telegram_emoji",
+		MaxSide:     100,
+		Square:      true,
+		Codec:       "png",
+		Encoder:     "png",
+		Container:   "png",
+		PixelFormat: "rgba",
+	}
+)
@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+type EncodeProgress struct {
+	Pass       int
+	OutSeconds float64
+	SizeBytes  int64
+	Done       bool
+}
+
+func (p EncodeProgress) Percent(durationSeconds float64, passes int) float64 {
+	if durationSeconds <= 0 {
+		return 0
+	}
+	if passes < 1 {
+		passes = 1
+	}
+	pass := min(max(p.Pass, 1), passes)
+	fraction := min(p.OutSeconds/durationSeconds, 1)
+	if p.Done {
+		fraction = 1
+	}
+	return (float64(pass-1) + fraction) / float64(passes) * 100
+}
+
+type AttemptTooLargeError struct {
+	SizeBytes      int64
+	ProjectedBytes int64
+	LimitBytes     int64
+}
+
+func (e *AttemptTooLargeError) Error() string {
+	return fmt.Sprintf("aborted after writing %d bytes, over the %d byte limit", e.SizeBytes, e.LimitBytes)
+}
+
+func ProjectSize(progress EncodeProgress, durationSeconds float64) int64 {
+	if progress.OutSeconds <= 0 || durationSeconds <= progress.OutSeconds {
+		return progress.SizeBytes
+	}
+	return int64(float64(progress.SizeBytes) * durationSeconds / progress.OutSeconds)
+}
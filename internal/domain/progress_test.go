@@ -0,0 +1,39 @@
+package domain
+
+import "testing"
+
+func TestProjectSize(t *testing.T) {
+	cases := []struct {
+		progress EncodeProgress
+		duration float64
+		want     int64
+	}{
+		{progress: EncodeProgress{OutSeconds: 1, SizeBytes: 100}, duration: 3, want: 300},
+		{progress: EncodeProgress{OutSeconds: 0, SizeBytes: 100}, duration: 3, want: 100},
+		{progress: EncodeProgress{OutSeconds: 3, SizeBytes: 100}, duration: 3, want: 100},
+	}
+	for _, tc := range cases {
+		if got := ProjectSize(tc.progress, tc.duration); got != tc.want {
+			t.Fatalf("unexpected projection for %+v: %d want %d", tc.progress, got, tc.want)
+		}
+	}
+}
+
+func TestEncodeProgressPercent(t *testing.T) {
+	cases := []struct {
+		progress EncodeProgress
+		passes   int
+		want     float64
+	}{
+		{progress: EncodeProgress{Pass: 1, OutSeconds: 1.5}, passes: 1, want: 50},
+		{progress: EncodeProgress{Pass: 1, OutSeconds: 1.5}, passes: 2, want: 25},
+		{progress: EncodeProgress{Pass: 2, OutSeconds: 1.5}, passes: 2, want: 75},
+		{progress: EncodeProgress{Pass: 2, OutSeconds: 9}, passes: 2, want: 100},
+		{progress: EncodeProgress{Pass: 1, Done: true}, passes: 1, want: 100},
+	}
+	for _, tc := range cases {
+		if got := tc.progress.Percent(3, tc.passes); got != tc.want {
+			t.Fatalf("percent for %+v/%d: got %v want %v", tc.progress, tc.passes, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "fmt"
+
+type QualityMetric string
+
+const (
+	QualityOff  QualityMetric = ""
+	QualitySSIM QualityMetric = "ssim"
+	QualityPSNR QualityMetric = "psnr"
+)
+
+const MaxQualityCandidates = 3
+
+type QualityScore struct {
+	Metric QualityMetric
+	Value  float64
+}
+
+type QualityReference struct {
+	Window          TrimWindow
+	Crop            CropRect
+	Speed           float64
+	Width           int
+	Height          int
+	PadSide         int
+	CandidateWidth  int
+	CandidateHeight int
+}
+
+func ParseQualityMetric(value string) (QualityMetric, error) {
+	switch QualityMetric(value) {
+	case QualityOff, QualitySSIM, QualityPSNR:
+		return QualityMetric(value), nil
+	case "off", "none":
+		return QualityOff, nil
+	default:
+		return "", fmt.Errorf("unknown quality metric: %s", value)
+	}
+}
+
+func QualityCandidateLevels(attempts []EncodeAttempt, profile Profile, limit int) [][]EncodeAttempt {
+	groups := make([][]EncodeAttempt, 0)
+	index := make(map[[3]int]int)
+	for _, a := range attempts {
+		if !profile.Square && len(validateDimensions(a.Width, a.Height, profile)) > 0 {
+			continue
+		}
+		key := [3]int{a.Width, a.Height, a.FPS}
+		i, ok := index[key]
+		if !ok {
+			if limit > 0 && len(groups) >= limit {
+				continue
+			}
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], a)
+	}
+	return groups
+}
+
+func NewQualityReference(first EncodeAttempt, opts EncodeOptions) QualityReference {
+	ref := QualityReference{
+		Window: opts.Window,
+		Crop:   opts.Crop,
+		Speed:  first.Speed,
+		Width:  first.Width,
+		Height: first.Height,
+	}
+	if opts.Profile.Square {
+		ref.PadSide = opts.Profile.MaxSide
+	}
+	return ref
+}
+
+func (r QualityReference) ForCandidate(a EncodeAttempt) QualityReference {
+	r.CandidateWidth = a.Width
+	r.CandidateHeight = a.Height
+	return r
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "testing"
+
+func TestQualityCandidateLevels(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 60, DurationSeconds: 3}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	groups := QualityCandidateLevels(attempts, TelegramVideoSticker, MaxQualityCandidates)
+	if len(groups) != MaxQualityCandidates {
+		t.Fatalf("unexpected group count: %d", len(groups))
+	}
+	fps := make(map[int]bool)
+	for _, g := range groups {
+		for _, a := range g {
+			if a.Width != g[0].Width || a.FPS != g[0].FPS {
+				t.Fatalf("mixed level in group: %+v", g)
+			}
+		}
+		if issues := validateDimensions(g[0].Width, g[0].Height, TelegramVideoSticker); len(issues) > 0 {
+			t.Fatalf("candidate can never pass: %+v %v", g[0], issues)
+		}
+		fps[g[0].FPS] = true
+	}
+	if len(fps) != len(groups) {
+		t.Fatalf("expected distinct fps variants, got %+v", groups)
+	}
+
+	emoji, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoEmoji})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	padded := QualityCandidateLevels(emoji, TelegramVideoEmoji, MaxQualityCandidates)
+	if len(padded) != MaxQualityCandidates || padded[0][0].Width != 100 || padded[1][0].Width >= 100 {
+		t.Fatalf("expected padded size levels for square profile, got %+v %+v", padded[0][0], padded[1][0])
+	}
+}
+
+func TestParseQualityMetric(t *testing.T) {
+	for input, want := range map[string]QualityMetric{"": QualityOff, "off": QualityOff, "ssim": QualitySSIM, "psnr": QualityPSNR} {
+		got, err := ParseQualityMetric(input)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %s err=%v", input, got, err)
+		}
+	}
+	if _, err := ParseQualityMetric("vmaf"); err == nil {
+		t.Fatalf("expected error for unknown metric")
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "math"
+
+const (
+	AutoSegmentMinRatio    = 2.0
+	AutoSegmentStepSeconds = 0.25
+)
+
+type FrameScore struct {
+	TimeSeconds float64
+	Score       float64
+}
+
+func AutoSegmentSpan(sourceSeconds float64, maxSeconds int, policy DurationPolicy) (float64, bool) {
+	if maxSeconds <= 0 || policy.Mode == DurationSpeedUp {
+		return 0, false
+	}
+	span := float64(maxSeconds)
+	if policy.Mode == DurationSpeedUpCapped {
+		span *= policy.Speed(math.MaxFloat64, maxSeconds)
+	}
+	if sourceSeconds < span*AutoSegmentMinRatio {
+		return 0, false
+	}
+	return span, true
+}
+
+func BestSegment(scores []FrameScore, sourceSeconds float64, span float64) TrimWindow {
+	if span <= 0 || sourceSeconds <= span {
+		return TrimWindow{}
+	}
+	bestStart := 0.0
+	bestScore := -1.0
+	for start := 0.0; start+span <= sourceSeconds+1e-9; start += AutoSegmentStepSeconds {
+		score := 0.0
+		for _, s := range scores {
+			if s.TimeSeconds >= start && s.TimeSeconds < start+span {
+				score += s.Score
+			}
+		}
+		if score > bestScore {
+			bestScore = score
+			bestStart = start
+		}
+	}
+	return TrimWindow{StartSeconds: bestStart, EndSeconds: bestStart + span}
+}
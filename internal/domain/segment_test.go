@@ -0,0 +1,40 @@
+package domain
+
+import "testing"
+
+func TestAutoSegmentSpan(t *testing.T) {
+	if _, ok := AutoSegmentSpan(5, 3, DurationPolicy{Mode: DurationTrim}); ok {
+		t.Fatalf("expected short clip to keep the first segment")
+	}
+	span, ok := AutoSegmentSpan(20, 3, DurationPolicy{Mode: DurationTrim})
+	if !ok || span != 3 {
+		t.Fatalf("unexpected span: %v ok=%v", span, ok)
+	}
+	span, ok = AutoSegmentSpan(20, 3, DurationPolicy{Mode: DurationSpeedUpCapped, MaxSpeed: 1.5})
+	if !ok || span != 4.5 {
+		t.Fatalf("unexpected capped span: %v ok=%v", span, ok)
+	}
+	if _, ok := AutoSegmentSpan(20, 3, DurationPolicy{Mode: DurationSpeedUp}); ok {
+		t.Fatalf("expected speed-up to keep the whole clip")
+	}
+}
+
+func TestBestSegment(t *testing.T) {
+	scores := []FrameScore{
+		{TimeSeconds: 1, Score: 0.1},
+		{TimeSeconds: 6.2, Score: 0.4},
+		{TimeSeconds: 7, Score: 0.5},
+		{TimeSeconds: 8.1, Score: 0.3},
+	}
+	got := BestSegment(scores, 12, 3)
+	if got.StartSeconds != 5.25 || got.EndSeconds != 8.25 {
+		t.Fatalf("unexpected segment: %+v", got)
+	}
+
+	if got := BestSegment(nil, 12, 3); got.StartSeconds != 0 || got.EndSeconds != 3 {
+		t.Fatalf("expected first segment without scores, got %+v", got)
+	}
+	if got := BestSegment(scores, 2, 3); !got.IsZero() {
+		t.Fatalf("expected no segment for short clip, got %+v", got)
+	}
+}
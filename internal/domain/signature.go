@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SniffHeaderBytes   = 4096
+	MaxLottieJSONBytes = 8 << 20
+)
+
+type FileFormat string
+
+const (
+	FormatPNG      FileFormat = "png"
+	FormatJPEG     FileFormat = "jpeg"
+	FormatGIF      FileFormat = "gif"
+	FormatWebP     FileFormat = "webp"
+	FormatMatroska FileFormat = "matroska"
+	FormatISOBMFF  FileFormat = "mp4"
+	FormatAVI      FileFormat = "avi"
+	FormatTGS      FileFormat = "tgs"
+	FormatLottie   FileFormat = "lottie"
+)
+
+type Detection struct {
+	Kind    InputKind
+	Format  FileFormat
+	Warning string
+}
+
+var extFormats = map[string]FileFormat{
+	".png":  FormatPNG,
+	".jpg":  FormatJPEG,
+	".jpeg": FormatJPEG,
+	".gif":  FormatGIF,
+	".webp": FormatWebP,
+	".webm": FormatMatroska,
+	".mkv":  FormatMatroska,
+	".mp4":  FormatISOBMFF,
+	".mov":  FormatISOBMFF,
+	".avi":  FormatAVI,
+	".tgs":  FormatTGS,
+	".json": FormatLottie,
+}
+
+var isoBoxTypes = [][]byte{[]byte("ftyp"), []byte("moov"), []byte("mdat"), []byte("wide"), []byte("free")}
+
+func SniffFormat(header []byte) (FileFormat, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG, true
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG, true
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return FormatGIF, true
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return FormatMatroska, true
+	}
+	if len(header) >= 12 && bytes.Equal(header[:4], []byte("RIFF")) {
+		switch string(header[8:12]) {
+		case "WEBP":
+			return FormatWebP, true
+		case "AVI ":
+			return FormatAVI, true
+		}
+	}
+	if len(header) >= 8 {
+		for _, box := range isoBoxTypes {
+			if bytes.Equal(header[4:8], box) {
+				return FormatISOBMFF, true
+			}
+		}
+	}
+	return "", false
+}
+
+func SniffBytesFor(path string) int {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return MaxLottieJSONBytes
+	}
+	return SniffHeaderBytes
+}
+
+func IsGzipHeader(header []byte) bool {
+	return bytes.HasPrefix(header, []byte{0x1F, 0x8B})
+}
+
+var lottieRequiredKeys = []string{"v", "fr", "w", "h", "layers"}
+
+func IsLottieJSON(data []byte) bool {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return false
+	}
+	for _, key := range lottieRequiredKeys {
+		if _, ok := root[key]; !ok {
+			return false
+		}
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(root["layers"]), []byte("["))
+}
+
+func KindForFormat(format FileFormat) InputKind {
+	switch format {
+	case FormatGIF:
+		return InputKindGIF
+	case FormatPNG, FormatJPEG, FormatWebP:
+		return InputKindImage
+	case FormatTGS, FormatLottie:
+		return InputKindLottie
+	default:
+		return InputKindVideo
+	}
+}
+
+func DetectInput(path string, header []byte) (Detection, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case IsGzipHeader(header):
+		if ext != ".tgs" {
+			return Detection{}, fmt.Errorf("unsupported input: %s is gzip data but not a .tgs sticker", path)
+		}
+		return Detection{Kind: InputKindLottie, Format: FormatTGS}, nil
+	case ext == ".json":
+		if !IsLottieJSON(header) {
+			return Detection{}, fmt.Errorf("unsupported input: %s is not a Lottie animation", path)
+		}
+		return Detection{Kind: InputKindLottie, Format: FormatLottie}, nil
+	}
+
+	format, ok := SniffFormat(header)
+	if !ok {
+		kind, err := DetectInputKind(path)
+		if err != nil {
+			return Detection{}, err
+		}
+		return Detection{Kind: kind, Format: extFormats[ext]}, nil
+	}
+
+	detection := Detection{Kind: KindForFormat(format), Format: format}
+	if IsAnimatedHeader(format, header) {
+		detection.Kind = InputKindAnimatedImage
+	}
+	if extFormat, known := extFormats[ext]; !known || extFormat != format {
+		detection.Warning = fmt.Sprintf("extension %q does not match %s content", ext, format)
+	}
+	return detection, nil
+}
+
+func IsAnimatedHeader(format FileFormat, header []byte) bool {
+	switch format {
+	case FormatWebP:
+		return isAnimatedWebP(header)
+	case FormatPNG:
+		return isAPNG(header)
+	default:
+		return false
+	}
+}
+
+func isAnimatedWebP(header []byte) bool {
+	if len(header) < 21 || string(header[12:16]) != "VP8X" {
+		return false
+	}
+	return header[20]&0x02 != 0
+}
+
+func isAPNG(header []byte) bool {
+	offset := 8
+	for offset+8 <= len(header) {
+		length := int(binary.BigEndian.Uint32(header[offset : offset+4]))
+		switch string(header[offset+4 : offset+8]) {
+		case "acTL":
+			return true
+		case "IDAT", "IEND":
+			return false
+		}
+		offset += 12 + length
+	}
+	return false
+}
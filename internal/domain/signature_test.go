@@ -0,0 +1,120 @@
+package domain
+
+import "testing"
+
+func TestSniffFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		header []byte
+		want   FileFormat
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), FormatPNG},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, FormatJPEG},
+		{"gif", []byte("GIF89a\x01\x00"), FormatGIF},
+		{"webp", []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), FormatWebP},
+		{"avi", []byte("RIFF\x10\x00\x00\x00AVI LIST"), FormatAVI},
+		{"webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F}, FormatMatroska},
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom"), FormatISOBMFF},
+		{"mov", []byte("\x00\x00\x00\x08wide\x00"), FormatISOBMFF},
+	}
+	for _, c := range cases {
+		got, ok := SniffFormat(c.header)
+		if !ok || got != c.want {
+			t.Fatalf("%s: got=%s ok=%v want=%s", c.name, got, ok, c.want)
+		}
+	}
+
+	if _, ok := SniffFormat([]byte("hello")); ok {
+		t.Fatal("expected unknown format")
+	}
+}
+
+func TestDetectInput(t *testing.T) {
+	gif := []byte("GIF89a\x01\x00")
+
+	mismatch, err := DetectInput("clip.mp4", gif)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if mismatch.Kind != InputKindGIF || mismatch.Warning == "" {
+		t.Fatalf("unexpected detection: %+v", mismatch)
+	}
+
+	match, err := DetectInput("clip.GIF", gif)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if match.Kind != InputKindGIF || match.Warning != "" {
+		t.Fatalf("unexpected detection: %+v", match)
+	}
+
+	fallback, err := DetectInput("clip.mov", []byte("x"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if fallback.Kind != InputKindVideo || fallback.Format != FormatISOBMFF {
+		t.Fatalf("unexpected detection: %+v", fallback)
+	}
+
+	if _, err := DetectInput("notes.txt", []byte("x")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDetectInputAnimated(t *testing.T) {
+	apng := []byte("\x89PNG\r\n\x1a\n" +
+		"\x00\x00\x00\x0dIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x00\x00\x00\x00" +
+		"\x00\x00\x00\x08acTL\x00\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00")
+	png := []byte("\x89PNG\r\n\x1a\n" +
+		"\x00\x00\x00\x0dIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x00\x00\x00\x00" +
+		"\x00\x00\x00\x00IDAT\x00\x00\x00\x00")
+	animatedWebP := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x12\x00\x00\x00")
+	staticWebP := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x10\x00\x00\x00")
+
+	cases := []struct {
+		name   string
+		path   string
+		header []byte
+		want   InputKind
+	}{
+		{"apng", "a.png", apng, InputKindAnimatedImage},
+		{"png", "a.png", png, InputKindImage},
+		{"animated-webp", "a.webp", animatedWebP, InputKindAnimatedImage},
+		{"static-webp", "a.webp", staticWebP, InputKindImage},
+	}
+	for _, c := range cases {
+		got, err := DetectInput(c.path, c.header)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", c.name, err)
+		}
+		if got.Kind != c.want {
+			t.Fatalf("%s: kind=%s want=%s", c.name, got.Kind, c.want)
+		}
+	}
+}
+
+func TestDetectInputLottieAndGzip(t *testing.T) {
+	gzip := []byte{0x1F, 0x8B, 0x08, 0x00}
+	tgs, err := DetectInput("wave.tgs", gzip)
+	if err != nil || tgs.Kind != InputKindLottie || tgs.Format != FormatTGS {
+		t.Fatalf("unexpected tgs detection: %+v %v", tgs, err)
+	}
+	if _, err := DetectInput("backup.tar.gz", gzip); err == nil {
+		t.Fatal("expected gzip archive to be rejected")
+	}
+
+	lottie := []byte(`{"v":"5.7.4","fr":60,"ip":0,"op":180,"w":512,"h":512,"layers":[]}`)
+	doc, err := DetectInput("wave.json", lottie)
+	if err != nil || doc.Kind != InputKindLottie || doc.Format != FormatLottie {
+		t.Fatalf("unexpected lottie detection: %+v %v", doc, err)
+	}
+	for _, data := range []string{
+		`{"name":"app","version":"1.0.0","dependencies":{}}`,
+		`{"v":"5.7.4","fr":60,"w":512,"h":512,"layers":{}}`,
+		`{"v":"5.7.4","fr":60,"w":512,"h":512,"layers":[`,
+	} {
+		if _, err := DetectInput("package.json", []byte(data)); err == nil {
+			t.Fatalf("expected %s to be rejected", data)
+		}
+	}
+}
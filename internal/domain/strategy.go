@@ -10,47 +10,75 @@ type EncodeAttempt struct {
 	DurationSeconds int
 	InputKind       InputKind
 	LoopSeconds     int
+	Speed           float64
+	Alpha           bool
+	Mode            EncodeMode
+	CRF             int
 }
 
-func BuildAttempts(info MediaInfo, kind InputKind) ([]EncodeAttempt, error) {
-	scaled, err := ScaleToFit(Size{Width: info.Width, Height: info.Height}, MaxStickerSide)
+func BuildAttempts(info MediaInfo, kind InputKind, opts EncodeOptions) ([]EncodeAttempt, error) {
+	profile := opts.Profile
+	speed := 1.0
+	if SupportsTrimWindow(kind) && !opts.Window.IsZero() {
+		length, err := opts.Window.Length(info.DurationSeconds)
+		if err != nil {
+			return nil, err
+		}
+		info.DurationSeconds = length
+	}
+	if kind != InputKindImage {
+		speed = opts.Duration.Speed(info.DurationSeconds, profile.MaxDurationSeconds)
+	}
+	if opts.ChromaKey.Enabled() {
+		info.HasAlpha = true
+	}
+	if !opts.Crop.IsZero() {
+		info.Width = opts.Crop.Width
+		info.Height = opts.Crop.Height
+	}
+	if speed != 1 {
+		info.FPS *= speed
+		info.DurationSeconds /= speed
+		info.BitrateBps = int64(float64(info.BitrateBps) * speed)
+	}
+
+	mode, crf := attemptMode(opts)
+
+	scaled, err := ScaleToFit(Size{Width: info.Width, Height: info.Height}, profile.MaxSide)
 	if err != nil {
 		return nil, err
 	}
 
-	baseAttemptFPS := pickBaseAttemptFPS(info, kind)
-	fallbackBaseFPS, allowFPSFallback := pickFallbackBaseFPS(info, kind)
+	baseAttemptFPS := pickBaseAttemptFPS(info, kind, profile)
+	fallbackBaseFPS, allowFPSFallback := pickFallbackBaseFPS(info, kind, profile)
 	fpsFallbackSteps := buildFPSFallbackSteps(fallbackBaseFPS, allowFPSFallback)
 
-	baseDuration := MaxStickerDurationSeconds
-	if info.DurationSeconds > 0 && info.DurationSeconds < float64(MaxStickerDurationSeconds) {
+	baseDuration := profile.MaxDurationSeconds
+	if info.DurationSeconds > 0 && info.DurationSeconds < float64(profile.MaxDurationSeconds) {
 		baseDuration = int(math.Ceil(info.DurationSeconds))
 	}
 
-	if kind == InputKindImage {
-		baseDuration = DefaultImageDuration
-	}
-	if kind == InputKindGIF {
-		baseDuration = DefaultImageDuration
+	if kind == InputKindImage || IsAnimatedKind(kind) {
+		baseDuration = profile.MaxDurationSeconds
 	}
 
 	if baseDuration <= 0 {
-		baseDuration = MaxStickerDurationSeconds
+		baseDuration = profile.MaxDurationSeconds
 	}
 
-	bitrateBase := int(float64(MaxStickerSizeBytes*8) / float64(baseDuration) / 1000.0)
+	bitrateBase := int(float64(profile.MaxSizeBytes*8) / float64(baseDuration) / 1000.0)
 	if bitrateBase < 150 {
 		bitrateBase = 150
 	}
 
 	bitrateSteps := []float64{1.0, 0.85, 0.7, 0.55, 0.45, 0.3}
 	sourceSizeBytes := estimateSourceSizeBytes(info.InputSizeBytes, info.BitrateBps, baseDuration)
-	bitrateSteps = chooseBitrateSteps(bitrateSteps, sourceSizeBytes, MaxStickerSizeBytes)
+	bitrateSteps = chooseBitrateSteps(bitrateSteps, sourceSizeBytes, profile.MaxSizeBytes)
 	scaleSteps := []float64{1.0, 0.9, 0.8, 0.7, 0.6}
 
 	loopSeconds := 0
-	if kind == InputKindImage || kind == InputKindGIF {
-		loopSeconds = DefaultImageDuration
+	if kind == InputKindImage || IsAnimatedKind(kind) {
+		loopSeconds = profile.MaxDurationSeconds
 	}
 
 	attempts := make([]EncodeAttempt, 0)
@@ -63,6 +91,10 @@ func BuildAttempts(info MediaInfo, kind InputKind) ([]EncodeAttempt, error) {
 			DurationSeconds: baseDuration,
 			InputKind:       kind,
 			LoopSeconds:     loopSeconds,
+			Speed:           speed,
+			Alpha:           info.HasAlpha,
+			Mode:            mode,
+			CRF:             crf,
 		})
 	}
 
@@ -84,6 +116,10 @@ func BuildAttempts(info MediaInfo, kind InputKind) ([]EncodeAttempt, error) {
 				DurationSeconds: baseDuration,
 				InputKind:       kind,
 				LoopSeconds:     loopSeconds,
+				Speed:           speed,
+				Alpha:           info.HasAlpha,
+				Mode:            mode,
+				CRF:             crf,
 			})
 		}
 	}
@@ -98,6 +134,10 @@ func BuildAttempts(info MediaInfo, kind InputKind) ([]EncodeAttempt, error) {
 				DurationSeconds: baseDuration,
 				InputKind:       kind,
 				LoopSeconds:     loopSeconds,
+				Speed:           speed,
+				Alpha:           info.HasAlpha,
+				Mode:            mode,
+				CRF:             crf,
 			})
 		}
 	}
@@ -105,24 +145,39 @@ func BuildAttempts(info MediaInfo, kind InputKind) ([]EncodeAttempt, error) {
 	return attempts, nil
 }
 
-func pickBaseAttemptFPS(info MediaInfo, kind InputKind) int {
+func attemptMode(opts EncodeOptions) (EncodeMode, int) {
+	switch opts.Mode {
+	case EncodeSinglePass:
+		return EncodeSinglePass, 0
+	case EncodeConstrainedQuality:
+		crf := opts.CRF
+		if crf < MinCRF || crf > MaxCRF {
+			crf = DefaultCRF
+		}
+		return EncodeConstrainedQuality, crf
+	default:
+		return EncodeTwoPass, 0
+	}
+}
+
+func pickBaseAttemptFPS(info MediaInfo, kind InputKind, profile Profile) int {
 	if kind == InputKindImage {
-		return DefaultImageFPS
+		return profile.MaxFPS
 	}
-	if info.FPS > float64(MaxStickerFPS) {
-		return MaxStickerFPS
+	if info.FPS > float64(profile.MaxFPS) {
+		return profile.MaxFPS
 	}
 	return 0
 }
 
-func pickFallbackBaseFPS(info MediaInfo, kind InputKind) (int, bool) {
+func pickFallbackBaseFPS(info MediaInfo, kind InputKind, profile Profile) (int, bool) {
 	if kind == InputKindImage {
-		return DefaultImageFPS, true
+		return profile.MaxFPS, true
 	}
 	if info.FPS <= 0 {
 		return 0, false
 	}
-	baseFPS := int(math.Min(info.FPS, float64(MaxStickerFPS)))
+	baseFPS := int(math.Min(info.FPS, float64(profile.MaxFPS)))
 	if baseFPS <= 0 {
 		return 0, false
 	}
@@ -154,7 +209,7 @@ func estimateSourceSizeBytes(inputSizeBytes int64, bitrateBps int64, durationSec
 	return sizeByBitrate
 }
 
-func chooseBitrateSteps(steps []float64, sourceSizeBytes int64, targetSizeBytes int) []float64 {
+func chooseBitrateSteps(steps []float64, sourceSizeBytes int64, targetSizeBytes int64) []float64 {
 	if sourceSizeBytes <= 0 || targetSizeBytes <= 0 {
 		return steps
 	}
@@ -4,7 +4,7 @@ import "testing"
 
 func TestBuildAttemptsOrder(t *testing.T) {
 	info := MediaInfo{Width: 1000, Height: 500, FPS: 60, DurationSeconds: 2.5}
-	attempts, err := BuildAttempts(info, InputKindVideo)
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -21,7 +21,7 @@ func TestBuildAttemptsOrder(t *testing.T) {
 
 func TestBuildAttemptsPreserveFPSWhenWithinLimit(t *testing.T) {
 	info := MediaInfo{Width: 512, Height: 256, FPS: 25, DurationSeconds: 2.5}
-	attempts, err := BuildAttempts(info, InputKindVideo)
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -40,7 +40,7 @@ func TestBuildAttemptsPreserveFPSWhenWithinLimit(t *testing.T) {
 
 func TestBuildAttemptsSkipFPSFallbackWhenUnknown(t *testing.T) {
 	info := MediaInfo{Width: 512, Height: 256, FPS: 0, DurationSeconds: 2.5}
-	attempts, err := BuildAttempts(info, InputKindVideo)
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -70,7 +70,7 @@ func TestBuildAttemptsAdaptiveBitrateStep(t *testing.T) {
 			info := baseInfo
 			info.InputSizeBytes = tc.inputSizeBytes
 			info.BitrateBps = tc.bitrateBps
-			attempts, err := BuildAttempts(info, InputKindVideo)
+			attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
 			if err != nil {
 				t.Fatalf("unexpected err: %v", err)
 			}
@@ -85,10 +85,122 @@ func TestBuildAttemptsAdaptiveBitrateStep(t *testing.T) {
 	}
 }
 
+func TestBuildAttemptsLoopsAnimatedImages(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 20, DurationSeconds: 1.2}
+	attempts, err := BuildAttempts(info, InputKindAnimatedImage, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].LoopSeconds != 3 || attempts[0].DurationSeconds != 3 {
+		t.Fatalf("expected looped attempt, got: %+v", attempts[0])
+	}
+}
+
 func expectedBaseBitrateKbps(durationSeconds int) int {
-	bitrate := int(float64(MaxStickerSizeBytes*8) / float64(durationSeconds) / 1000.0)
+	bitrate := int(float64(TelegramVideoSticker.MaxSizeBytes*8) / float64(durationSeconds) / 1000.0)
 	if bitrate < 150 {
 		bitrate = 150
 	}
 	return bitrate
 }
+
+func TestBuildAttemptsSpeedUp(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 25, DurationSeconds: 6}
+	opts := EncodeOptions{Profile: TelegramVideoSticker, Duration: DurationPolicy{Mode: DurationSpeedUp}}
+	attempts, err := BuildAttempts(info, InputKindVideo, opts)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].Speed != 2 {
+		t.Fatalf("unexpected speed: %v", attempts[0].Speed)
+	}
+	if attempts[0].FPS != 30 {
+		t.Fatalf("expected fps cap after speed-up, got %d", attempts[0].FPS)
+	}
+	if attempts[0].DurationSeconds != 3 {
+		t.Fatalf("unexpected duration: %d", attempts[0].DurationSeconds)
+	}
+}
+
+func TestDurationPolicySpeed(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy DurationPolicy
+		source float64
+		want   float64
+	}{
+		{name: "trim", policy: DurationPolicy{Mode: DurationTrim}, source: 9, want: 1},
+		{name: "short clip", policy: DurationPolicy{Mode: DurationSpeedUp}, source: 2, want: 1},
+		{name: "speedup", policy: DurationPolicy{Mode: DurationSpeedUp}, source: 9, want: 3},
+		{name: "capped", policy: DurationPolicy{Mode: DurationSpeedUpCapped, MaxSpeed: 1.5}, source: 9, want: 1.5},
+		{name: "under cap", policy: DurationPolicy{Mode: DurationSpeedUpCapped, MaxSpeed: 4}, source: 6, want: 2},
+		{name: "default cap", policy: DurationPolicy{Mode: DurationSpeedUpCapped}, source: 12, want: DefaultMaxSpeed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.policy.Speed(tc.source, 3); got != tc.want {
+				t.Fatalf("unexpected speed: %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildAttemptsTrimWindow(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 10}
+	opts := EncodeOptions{Profile: TelegramVideoSticker, Window: TrimWindow{StartSeconds: 8}}
+	attempts, err := BuildAttempts(info, InputKindVideo, opts)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].DurationSeconds != 2 {
+		t.Fatalf("expected window duration, got %d", attempts[0].DurationSeconds)
+	}
+
+	opts.Window = TrimWindow{StartSeconds: 11}
+	if _, err := BuildAttempts(info, InputKindVideo, opts); err == nil {
+		t.Fatalf("expected error for window beyond source")
+	}
+}
+
+func TestBuildAttemptsChromaKeyNeedsAlpha(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 2}
+	opts := EncodeOptions{Profile: TelegramVideoSticker, ChromaKey: ChromaKey{Color: "0x00FF00", Similarity: 0.1}}
+	attempts, err := BuildAttempts(info, InputKindVideo, opts)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !attempts[0].Alpha {
+		t.Fatalf("expected alpha attempt: %+v", attempts[0])
+	}
+}
+
+func TestBuildAttemptsUsesCroppedSize(t *testing.T) {
+	info := MediaInfo{Width: 1920, Height: 1080, FPS: 30, DurationSeconds: 2}
+	opts := EncodeOptions{Profile: TelegramVideoSticker, Crop: CropRect{Width: 1080, Height: 1080, X: 420}}
+	attempts, err := BuildAttempts(info, InputKindVideo, opts)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].Width != 512 || attempts[0].Height != 512 {
+		t.Fatalf("expected square attempt, got %dx%d", attempts[0].Width, attempts[0].Height)
+	}
+}
+
+func TestBuildAttemptsEncodeMode(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 30, DurationSeconds: 2}
+	attempts, err := BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].Mode != EncodeTwoPass {
+		t.Fatalf("expected two-pass by default, got %s", attempts[0].Mode)
+	}
+
+	attempts, err = BuildAttempts(info, InputKindVideo, EncodeOptions{Profile: TelegramVideoSticker, Mode: EncodeConstrainedQuality})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if attempts[0].Mode != EncodeConstrainedQuality || attempts[0].CRF != DefaultCRF || attempts[0].BitrateKbps == 0 {
+		t.Fatalf("expected capped constrained quality attempt, got %+v", attempts[0])
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type TrimWindow struct {
+	StartSeconds float64
+	EndSeconds   float64
+}
+
+func SupportsTrimWindow(kind InputKind) bool {
+	return kind != InputKindImage && !IsAnimatedKind(kind)
+}
+
+func (w TrimWindow) IsZero() bool {
+	return w.StartSeconds == 0 && w.EndSeconds == 0
+}
+
+func (w TrimWindow) Validate() error {
+	if w.StartSeconds < 0 || w.EndSeconds < 0 {
+		return fmt.Errorf("trim offsets must not be negative")
+	}
+	if w.EndSeconds > 0 && w.EndSeconds <= w.StartSeconds {
+		return fmt.Errorf("trim end %s must be after start %s", FormatSeconds(w.EndSeconds), FormatSeconds(w.StartSeconds))
+	}
+	return nil
+}
+
+func (w TrimWindow) Length(sourceSeconds float64) (float64, error) {
+	if err := w.Validate(); err != nil {
+		return 0, err
+	}
+	if sourceSeconds <= 0 {
+		if w.EndSeconds > 0 {
+			return w.EndSeconds - w.StartSeconds, nil
+		}
+		return 0, nil
+	}
+	if w.StartSeconds >= sourceSeconds {
+		return 0, fmt.Errorf("trim start %s is beyond the %s source", FormatSeconds(w.StartSeconds), FormatSeconds(sourceSeconds))
+	}
+	end := sourceSeconds
+	if w.EndSeconds > 0 {
+		end = math.Min(end, w.EndSeconds)
+	}
+	return end - w.StartSeconds, nil
+}
+
+func ParseTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %s", value)
+	}
+	total := 0.0
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		if i > 0 && n >= 60 {
+			return 0, fmt.Errorf("invalid timestamp: %s", value)
+		}
+		total = total*60 + n
+	}
+	return total, nil
+}
+
+func FormatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64) + "s"
+}
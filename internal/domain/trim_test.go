@@ -0,0 +1,60 @@
+package domain
+
+import "testing"
+
+func TestParseTimestamp(t *testing.T) {
+	cases := map[string]float64{
+		"":        0,
+		"2.5":     2.5,
+		"0:05":    5,
+		"1:02.5":  62.5,
+		"1:00:03": 3603,
+		" 12 ":    12,
+	}
+	for input, want := range cases {
+		got, err := ParseTimestamp(input)
+		if err != nil {
+			t.Fatalf("unexpected err for %q: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("unexpected value for %q: %v want %v", input, got, want)
+		}
+	}
+	for _, input := range []string{"abc", "-1", "1:75", "1:2:3:4"} {
+		if _, err := ParseTimestamp(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestTrimWindowLength(t *testing.T) {
+	cases := []struct {
+		name   string
+		window TrimWindow
+		source float64
+		want   float64
+	}{
+		{name: "start only", window: TrimWindow{StartSeconds: 2}, source: 10, want: 8},
+		{name: "range", window: TrimWindow{StartSeconds: 2, EndSeconds: 4.5}, source: 10, want: 2.5},
+		{name: "end clamped", window: TrimWindow{StartSeconds: 8, EndSeconds: 20}, source: 10, want: 2},
+		{name: "unknown source", window: TrimWindow{StartSeconds: 1, EndSeconds: 3}, source: 0, want: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.window.Length(tc.source)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("unexpected length: %v want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := (TrimWindow{StartSeconds: 12}).Length(10); err == nil {
+		t.Fatalf("expected error for start beyond source")
+	}
+	if _, err := (TrimWindow{StartSeconds: 3, EndSeconds: 2}).Length(10); err == nil {
+		t.Fatalf("expected error for inverted window")
+	}
+}
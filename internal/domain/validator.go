@@ -1,38 +1,60 @@
 package domain
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type ValidationIssue struct {
 	Code    string
 	Message string
 }
 
-func ValidateOutput(info MediaInfo, sizeBytes int64) []ValidationIssue {
+func ValidateOutput(info MediaInfo, sizeBytes int64, profile Profile) []ValidationIssue {
 	issues := make([]ValidationIssue, 0)
-	if sizeBytes > MaxStickerSizeBytes {
+	if profile.MaxSizeBytes > 0 && sizeBytes > profile.MaxSizeBytes {
 		issues = append(issues, ValidationIssue{Code: "size", Message: "size exceeds limit"})
 	}
-	if info.FPS > MaxStickerFPS {
+	if profile.MaxFPS > 0 && info.FPS > float64(profile.MaxFPS) {
 		issues = append(issues, ValidationIssue{Code: "fps", Message: "fps exceeds limit"})
 	}
-	if info.DurationSeconds > float64(MaxStickerDurationSeconds) {
+	if profile.MaxDurationSeconds > 0 && info.DurationSeconds > float64(profile.MaxDurationSeconds) {
 		issues = append(issues, ValidationIssue{Code: "duration", Message: "duration exceeds limit"})
 	}
 	if info.HasAudio {
 		issues = append(issues, ValidationIssue{Code: "audio", Message: "audio stream present"})
 	}
-	if !strings.Contains(strings.ToLower(info.CodecName), "vp9") {
-		issues = append(issues, ValidationIssue{Code: "codec", Message: "codec is not vp9"})
+	if !strings.Contains(strings.ToLower(info.CodecName), profile.Codec) {
+		issues = append(issues, ValidationIssue{Code: "codec", Message: fmt.Sprintf("codec is not %s", profile.Codec)})
 	}
-	if !strings.Contains(strings.ToLower(info.FormatName), "webm") {
-		issues = append(issues, ValidationIssue{Code: "format", Message: "format is not webm"})
+	if !strings.Contains(strings.ToLower(info.FormatName), profile.Container) {
+		issues = append(issues, ValidationIssue{Code: "format", Message: fmt.Sprintf("format is not %s", profile.Container)})
 	}
-	if info.Width != MaxStickerSide && info.Height != MaxStickerSide {
-		issues = append(issues, ValidationIssue{Code: "size", Message: "one side must be 512"})
-	}
-	if info.Width > MaxStickerSide || info.Height > MaxStickerSide {
-		issues = append(issues, ValidationIssue{Code: "size", Message: "dimension exceeds 512"})
+	issues = append(issues, validateDimensions(info.Width, info.Height, profile)...)
+
+	return issues
+}
+
+func ValidateAlpha(expected bool, info MediaInfo) []ValidationIssue {
+	if expected && !info.HasAlpha {
+		return []ValidationIssue{{Code: "alpha", Message: "source transparency was lost"}}
 	}
+	return nil
+}
 
+func validateDimensions(width int, height int, profile Profile) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	if profile.Square {
+		if width != profile.MaxSide || height != profile.MaxSide {
+			issues = append(issues, ValidationIssue{Code: "dimension", Message: fmt.Sprintf("dimension must be %dx%d", profile.MaxSide, profile.MaxSide)})
+		}
+		return issues
+	}
+	if width != profile.MaxSide && height != profile.MaxSide {
+		issues = append(issues, ValidationIssue{Code: "dimension", Message: fmt.Sprintf("one side must be %d", profile.MaxSide)})
+	}
+	if width > profile.MaxSide || height > profile.MaxSide {
+		issues = append(issues, ValidationIssue{Code: "dimension", Message: fmt.Sprintf("dimension exceeds %d", profile.MaxSide)})
+	}
 	return issues
 }
@@ -4,8 +4,53 @@ import "testing"
 
 func TestValidateOutput(t *testing.T) {
 	info := MediaInfo{Width: 512, Height: 256, FPS: 30, DurationSeconds: 3.0, HasAudio: false, CodecName: "vp9", FormatName: "webm"}
-	issues := ValidateOutput(info, 200*1024)
+	issues := ValidateOutput(info, 200*1024, TelegramVideoSticker)
 	if len(issues) != 0 {
 		t.Fatalf("unexpected issues: %v", issues)
 	}
 }
+
+func TestValidateOutputReportsLimits(t *testing.T) {
+	info := MediaInfo{Width: 512, Height: 512, FPS: 60, DurationSeconds: 3.5, HasAudio: true, CodecName: "h264", FormatName: "mov,mp4"}
+	issues := ValidateOutput(info, 300*1024, TelegramVideoSticker)
+	codes := make(map[string]int)
+	for _, issue := range issues {
+		codes[issue.Code]++
+	}
+	for _, code := range []string{"size", "fps", "duration", "audio", "codec", "format"} {
+		if codes[code] == 0 {
+			t.Fatalf("missing %s issue: %v", code, issues)
+		}
+	}
+}
+
+func TestValidateOutputVideoEmoji(t *testing.T) {
+	info := MediaInfo{Width: 100, Height: 100, FPS: 30, DurationSeconds: 3.0, CodecName: "vp9", FormatName: "matroska,webm"}
+	if issues := ValidateOutput(info, 60*1024, TelegramVideoEmoji); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+
+	oversized := ValidateOutput(info, 70*1024, TelegramVideoEmoji)
+	if len(oversized) != 1 || oversized[0].Code != "size" {
+		t.Fatalf("expected size issue, got: %v", oversized)
+	}
+
+	notSquare := info
+	notSquare.Height = 56
+	issues := ValidateOutput(notSquare, 60*1024, TelegramVideoEmoji)
+	if len(issues) != 1 || issues[0].Message != "dimension must be 100x100" {
+		t.Fatalf("expected square issue, got: %v", issues)
+	}
+}
+
+func TestValidateAlpha(t *testing.T) {
+	if issues := ValidateAlpha(true, MediaInfo{HasAlpha: false}); len(issues) != 1 || issues[0].Code != "alpha" {
+		t.Fatalf("expected alpha issue, got %+v", issues)
+	}
+	if issues := ValidateAlpha(true, MediaInfo{HasAlpha: true}); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	if issues := ValidateAlpha(false, MediaInfo{}); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
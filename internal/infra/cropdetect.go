@@ -0,0 +1,57 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+const cropDetectSeconds = "30"
+
+var cropDetectPattern = regexp.MustCompile(`crop=(\d+:\d+:\d+:\d+)`)
+
+type CropDetector struct {
+	Path string
+}
+
+func (d CropDetector) DetectCrop(ctx context.Context, path string) (domain.CropRect, error) {
+	bin := d.Path
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	args := []string{
+		"-hide_banner", "-nostats",
+		"-t", cropDetectSeconds,
+		"-i", path,
+		"-an",
+		"-vf", "cropdetect",
+		"-f", "null", "-",
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return domain.CropRect{}, fmt.Errorf("crop detection failed: %w%s", err, formatFFmpegStderr(stderr.String()))
+	}
+	return parseCropDetect(stderr.String())
+}
+
+func parseCropDetect(output string) (domain.CropRect, error) {
+	counts := make(map[string]int)
+	best := ""
+	for _, match := range cropDetectPattern.FindAllStringSubmatch(output, -1) {
+		value := match[1]
+		counts[value]++
+		if best == "" || counts[value] > counts[best] {
+			best = value
+		}
+	}
+	if best == "" {
+		return domain.CropRect{}, nil
+	}
+	return domain.ParseCropRect(best)
+}
@@ -0,0 +1,26 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestParseCropDetect(t *testing.T) {
+	output := `[Parsed_cropdetect_0 @ 0x1] x1:0 x2:1919 y1:0 y2:1079 w:1920 h:1080 x:0 y:0 pts:0 t:0.000000 crop=1920:1080:0:0
+[Parsed_cropdetect_0 @ 0x1] x1:0 x2:1919 y1:140 y2:939 w:1920 h:800 x:0 y:140 pts:512 t:0.040000 crop=1920:800:0:140
+[Parsed_cropdetect_0 @ 0x1] x1:0 x2:1919 y1:140 y2:939 w:1920 h:800 x:0 y:140 pts:1024 t:0.080000 crop=1920:800:0:140
+`
+	got, err := parseCropDetect(output)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got != (domain.CropRect{Width: 1920, Height: 800, Y: 140}) {
+		t.Fatalf("unexpected crop: %+v", got)
+	}
+
+	empty, err := parseCropDetect("no crop lines")
+	if err != nil || !empty.IsZero() {
+		t.Fatalf("expected empty crop, got %+v err=%v", empty, err)
+	}
+}
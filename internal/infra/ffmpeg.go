@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	ffmpeg "github.com/u2takey/ffmpeg-go"
@@ -12,15 +14,34 @@ import (
 	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
 )
 
+const alphaPixelFormat = "yuva420p"
+
 type FFmpegRunner struct{}
 
 func (r FFmpegRunner) Encode(ctx context.Context, inputPath string, attempt domain.EncodeAttempt, outputPath string, opts domain.EncodeOptions) error {
-	inputKw := buildInputKwArgs(attempt)
+	inputKw := buildInputKwArgs(attempt, opts.Window)
 	stream := ffmpeg.Input(inputPath, inputKw).Silent(true)
 	stream.Context = ctx
 
+	if attempt.Alpha {
+		stream = stream.Filter("format", ffmpeg.Args{alphaPixelFormat})
+	}
+	if !opts.Crop.IsZero() {
+		stream = stream.Filter("crop", ffmpeg.Args{opts.Crop.String()})
+	}
+	if opts.ChromaKey.Enabled() {
+		stream = stream.Filter("chromakey", ffmpeg.Args{buildChromaKeyArg(opts.ChromaKey)})
+	}
+
 	scaleArg := fmt.Sprintf("%d:%d", attempt.Width, attempt.Height)
 	stream = stream.Filter("scale", ffmpeg.Args{scaleArg})
+	if opts.Profile.Square {
+		stream = stream.Filter("pad", ffmpeg.Args{buildPadArg(opts.Profile.MaxSide)})
+	}
+
+	if attempt.Speed > 0 && attempt.Speed != 1 {
+		stream = stream.Filter("setpts", ffmpeg.Args{buildSetptsArg(attempt.Speed)})
+	}
 
 	if attempt.FPS > 0 {
 		stream = stream.Filter("fps", ffmpeg.Args{fmt.Sprintf("%d", attempt.FPS)})
@@ -30,15 +51,74 @@ func (r FFmpegRunner) Encode(ctx context.Context, inputPath string, attempt doma
 		stream = stream.Trim(ffmpeg.KwArgs{"duration": fmt.Sprintf("%d", opts.TrimSeconds)})
 	}
 
-	outputKw := buildOutputKwArgs(attempt)
+	outputKw := buildOutputKwArgs(attempt, opts.Profile)
+	applyVPXTuning(outputKw, opts.Tuning)
+	if attempt.Mode != domain.EncodeTwoPass {
+		return runFFmpeg(ctx, stream.Output(outputPath, outputKw), outputPath, sizeLimit(attempt, opts, 1))
+	}
+
+	passLog := outputPath + ".pass"
+	defer os.Remove(passLog + "-0.log")
+	firstPass := buildPassKwArgs(outputKw, 1, passLog)
+	if err := runFFmpeg(ctx, stream.Output(os.DevNull, firstPass), outputPath, encodeLimit{Pass: 1, OnProgress: opts.OnProgress}); err != nil {
+		return err
+	}
+	return runFFmpeg(ctx, stream.Output(outputPath, buildPassKwArgs(outputKw, 2, passLog)), outputPath, sizeLimit(attempt, opts, 2))
+}
+
+type encodeLimit struct {
+	Pass            int
+	MaxBytes        int64
+	DurationSeconds float64
+	OnProgress      func(domain.EncodeProgress)
+}
+
+func sizeLimit(attempt domain.EncodeAttempt, opts domain.EncodeOptions, pass int) encodeLimit {
+	return encodeLimit{
+		Pass:            pass,
+		MaxBytes:        opts.Profile.MaxSizeBytes,
+		DurationSeconds: float64(attempt.DurationSeconds),
+		OnProgress:      opts.OnProgress,
+	}
+}
+
+func runFFmpeg(ctx context.Context, stream *ffmpeg.Stream, outputPath string, limit encodeLimit) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	err := stream.Output(outputPath, outputKw).
+	var tooLarge *domain.AttemptTooLargeError
+	progress := &progressWriter{onUpdate: func(p domain.EncodeProgress) {
+		p.Pass = limit.Pass
+		if limit.OnProgress != nil {
+			limit.OnProgress(p)
+		}
+		if tooLarge != nil || limit.MaxBytes <= 0 || p.SizeBytes <= limit.MaxBytes {
+			return
+		}
+		tooLarge = &domain.AttemptTooLargeError{
+			SizeBytes:      p.SizeBytes,
+			ProjectedBytes: domain.ProjectSize(p, limit.DurationSeconds),
+			LimitBytes:     limit.MaxBytes,
+		}
+		cancel()
+	}}
+
+	stream = stream.GlobalArgs("-progress", "pipe:1", "-nostats")
+	stream.Context = runCtx
+	err := stream.
 		OverWriteOutput().
-		WithOutput(&stdout, &stderr).
+		WithOutput(io.MultiWriter(&stdout, progress), &stderr).
 		Run()
 
+	if tooLarge != nil {
+		return tooLarge
+	}
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		stdoutText := stdout.String()
 		stderrText := stderr.String()
 		logPath, logErr := writeFFmpegErrorLog(outputPath, stdoutText, stderrText)
@@ -48,7 +128,7 @@ func (r FFmpegRunner) Encode(ctx context.Context, inputPath string, attempt doma
 		} else if logErr != nil {
 			suffix = fmt.Sprintf("%s (ffmpeg log write failed: %v)", suffix, logErr)
 		}
-		return fmt.Errorf("ffmpeg failed: %w%s", err, suffix)
+		return &domain.EncodeError{Err: fmt.Errorf("ffmpeg failed: %w%s", err, suffix), LogPath: logPath}
 	}
 	return nil
 }
@@ -57,63 +137,76 @@ func (r FFmpegRunner) EncodeImage(ctx context.Context, inputPath string, opts do
 	stream := ffmpeg.Input(inputPath).Silent(true)
 	stream.Context = ctx
 
-	scaleArg := buildImageScaleArg(opts.TargetSide)
+	scaleArg := buildImageScaleArg(opts.Profile.MaxSide)
 	stream = stream.Filter("scale", ffmpeg.Args{scaleArg})
-	if opts.PadToSquare {
-		padArg := buildImagePadArg(opts.TargetSide)
+	if opts.Profile.Square {
+		padArg := buildPadArg(opts.Profile.MaxSide)
 		stream = stream.Filter("pad", ffmpeg.Args{padArg})
 	}
 
-	outputKw := buildImageOutputKwArgs()
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	err := stream.Output(outputPath, outputKw).
-		OverWriteOutput().
-		WithOutput(&stdout, &stderr).
-		Run()
-
-	if err != nil {
-		stdoutText := stdout.String()
-		stderrText := stderr.String()
-		logPath, logErr := writeFFmpegErrorLog(outputPath, stdoutText, stderrText)
-		suffix := formatFFmpegStderr(stderrText)
-		if logErr == nil && logPath != "" {
-			suffix = fmt.Sprintf("%s (ffmpeg log: %s)", suffix, logPath)
-		} else if logErr != nil {
-			suffix = fmt.Sprintf("%s (ffmpeg log write failed: %v)", suffix, logErr)
-		}
-		return fmt.Errorf("ffmpeg failed: %w%s", err, suffix)
-	}
-	return nil
+	outputKw := buildImageOutputKwArgs(opts)
+	return runFFmpeg(ctx, stream.Output(outputPath, outputKw), outputPath, encodeLimit{})
 }
 
-func buildInputKwArgs(attempt domain.EncodeAttempt) ffmpeg.KwArgs {
+func buildInputKwArgs(attempt domain.EncodeAttempt, window domain.TrimWindow) ffmpeg.KwArgs {
 	kw := ffmpeg.KwArgs{}
+	if domain.SupportsTrimWindow(attempt.InputKind) {
+		if window.StartSeconds > 0 {
+			kw["ss"] = formatSeconds(window.StartSeconds)
+		}
+		if window.EndSeconds > 0 {
+			kw["t"] = formatSeconds(window.EndSeconds - window.StartSeconds)
+		}
+	}
 	if attempt.InputKind == domain.InputKindImage {
 		kw["loop"] = 1
 	}
-	if attempt.InputKind == domain.InputKindGIF {
+	if domain.IsAnimatedKind(attempt.InputKind) {
 		kw["stream_loop"] = -1
 	}
 	return kw
 }
 
+func buildSetptsArg(speed float64) string {
+	return fmt.Sprintf("PTS/%s", formatSeconds(speed))
+}
+
+func buildChromaKeyArg(key domain.ChromaKey) string {
+	return fmt.Sprintf("%s:%s:%s", key.Color, formatSeconds(key.Similarity), formatSeconds(key.Blend))
+}
+
+func formatSeconds(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
 func buildImageScaleArg(targetSide int) string {
 	return fmt.Sprintf("%d:%d:force_original_aspect_ratio=decrease", targetSide, targetSide)
 }
 
-func buildImagePadArg(targetSide int) string {
+func buildPadArg(targetSide int) string {
 	return fmt.Sprintf("%d:%d:(ow-iw)/2:(oh-ih)/2:color=0x00000000", targetSide, targetSide)
 }
 
-func buildOutputKwArgs(attempt domain.EncodeAttempt) ffmpeg.KwArgs {
+func buildOutputKwArgs(attempt domain.EncodeAttempt, profile domain.Profile) ffmpeg.KwArgs {
 	kw := ffmpeg.KwArgs{
-		"c:v": "libvpx-vp9",
+		"c:v": profile.Encoder,
 		"an":  "",
 	}
+	if profile.PixelFormat != "" {
+		kw["pix_fmt"] = profile.PixelFormat
+	}
+	if attempt.Alpha {
+		kw["pix_fmt"] = alphaPixelFormat
+	}
+	if profile.Container != "" {
+		kw["f"] = profile.Container
+	}
 	if attempt.BitrateKbps > 0 {
 		kw["b:v"] = fmt.Sprintf("%dk", attempt.BitrateKbps)
 	}
+	if attempt.Mode == domain.EncodeConstrainedQuality && attempt.CRF > 0 {
+		kw["crf"] = attempt.CRF
+	}
 	if attempt.FPS > 0 {
 		kw["r"] = fmt.Sprintf("%d", attempt.FPS)
 	} else {
@@ -125,12 +218,49 @@ func buildOutputKwArgs(attempt domain.EncodeAttempt) ffmpeg.KwArgs {
 	return kw
 }
 
-func buildImageOutputKwArgs() ffmpeg.KwArgs {
-	return ffmpeg.KwArgs{
+func applyVPXTuning(kw ffmpeg.KwArgs, tuning domain.VPXTuning) {
+	if tuning.IsZero() {
+		return
+	}
+	kw["deadline"] = tuning.Deadline
+	kw["cpu-used"] = tuning.CPUUsed
+	kw["tile-columns"] = tuning.TileColumns
+	if tuning.RowMT {
+		kw["row-mt"] = 1
+	} else {
+		kw["row-mt"] = 0
+	}
+}
+
+func buildPassKwArgs(kw ffmpeg.KwArgs, pass int, passLog string) ffmpeg.KwArgs {
+	passKw := ffmpeg.KwArgs{}
+	for k, v := range kw {
+		passKw[k] = v
+	}
+	passKw["pass"] = pass
+	passKw["passlogfile"] = passLog
+	if pass == 1 {
+		passKw["f"] = "null"
+	}
+	return passKw
+}
+
+func buildImageOutputKwArgs(opts domain.ImageEncodeOptions) ffmpeg.KwArgs {
+	kw := ffmpeg.KwArgs{
 		"vframes": 1,
-		"vcodec":  "png",
+		"vcodec":  opts.Profile.Encoder,
 		"f":       "image2",
 	}
+	if opts.Profile.Container == "webp" {
+		kw["f"] = "webp"
+	}
+	if opts.Profile.PixelFormat != "" {
+		kw["pix_fmt"] = opts.Profile.PixelFormat
+	}
+	if opts.Quality > 0 {
+		kw["quality"] = opts.Quality
+	}
+	return kw
 }
 
 func formatFFmpegStderr(stderr string) string {
@@ -9,25 +9,38 @@ import (
 )
 
 func TestBuildInputKwArgs(t *testing.T) {
-	img := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindImage})
+	img := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindImage}, domain.TrimWindow{StartSeconds: 1})
 	if v, ok := img["loop"]; !ok || v != 1 {
 		t.Fatalf("expected loop=1, got=%v", img)
 	}
 
-	gif := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindGIF})
+	gif := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindGIF}, domain.TrimWindow{})
 	if v, ok := gif["stream_loop"]; !ok || v != -1 {
 		t.Fatalf("expected stream_loop=-1, got=%v", gif)
 	}
 
-	vid := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindVideo})
+	animated := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindAnimatedImage}, domain.TrimWindow{})
+	if v, ok := animated["stream_loop"]; !ok || v != -1 {
+		t.Fatalf("expected stream_loop=-1, got=%v", animated)
+	}
+
+	vid := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindVideo}, domain.TrimWindow{})
 	if len(vid) != 0 {
 		t.Fatalf("expected empty args, got=%v", vid)
 	}
+
+	windowed := buildInputKwArgs(domain.EncodeAttempt{InputKind: domain.InputKindVideo}, domain.TrimWindow{StartSeconds: 1.5, EndSeconds: 4})
+	if windowed["ss"] != "1.5" || windowed["t"] != "2.5" {
+		t.Fatalf("expected seek and duration, got=%v", windowed)
+	}
+	if _, ok := img["ss"]; ok {
+		t.Fatalf("unexpected seek for image, got=%v", img)
+	}
 }
 
 func TestBuildOutputKwArgs(t *testing.T) {
 	attempt := domain.EncodeAttempt{FPS: 30, BitrateKbps: 500, DurationSeconds: 3}
-	got := buildOutputKwArgs(attempt)
+	got := buildOutputKwArgs(attempt, domain.TelegramVideoSticker)
 
 	if got["c:v"] != "libvpx-vp9" {
 		t.Fatalf("unexpected codec: %v", got["c:v"])
@@ -44,13 +57,16 @@ func TestBuildOutputKwArgs(t *testing.T) {
 	if _, ok := got["an"]; !ok {
 		t.Fatalf("expected an flag")
 	}
+	if got["pix_fmt"] != "yuv420p" || got["f"] != "webm" {
+		t.Fatalf("unexpected pixel format/container: %v", got)
+	}
 
 	_ = ffmpeg.KwArgs(got)
 }
 
 func TestBuildOutputKwArgsPreserveFPS(t *testing.T) {
 	attempt := domain.EncodeAttempt{FPS: 0, BitrateKbps: 500, DurationSeconds: 3}
-	got := buildOutputKwArgs(attempt)
+	got := buildOutputKwArgs(attempt, domain.TelegramVideoSticker)
 
 	if _, ok := got["r"]; ok {
 		t.Fatalf("unexpected fps override: %v", got["r"])
@@ -59,3 +75,76 @@ func TestBuildOutputKwArgsPreserveFPS(t *testing.T) {
 		t.Fatalf("unexpected fps_mode: %v", got["fps_mode"])
 	}
 }
+
+func TestBuildOutputKwArgsAlpha(t *testing.T) {
+	got := buildOutputKwArgs(domain.EncodeAttempt{BitrateKbps: 500, Alpha: true}, domain.TelegramVideoSticker)
+	if got["pix_fmt"] != "yuva420p" {
+		t.Fatalf("unexpected pix_fmt: %v", got["pix_fmt"])
+	}
+}
+
+func TestBuildOutputKwArgsConstrainedQuality(t *testing.T) {
+	attempt := domain.EncodeAttempt{BitrateKbps: 600, Mode: domain.EncodeConstrainedQuality, CRF: 30}
+	got := buildOutputKwArgs(attempt, domain.TelegramVideoSticker)
+	if got["crf"] != 30 || got["b:v"] != "600k" {
+		t.Fatalf("unexpected constrained quality args: %v", got)
+	}
+}
+
+func TestApplyVPXTuning(t *testing.T) {
+	kw := ffmpeg.KwArgs{}
+	applyVPXTuning(kw, domain.VPXTuning{})
+	if len(kw) != 0 {
+		t.Fatalf("expected no tuning args, got %v", kw)
+	}
+	applyVPXTuning(kw, domain.DefaultVPXTuning)
+	if kw["deadline"] != "good" || kw["cpu-used"] != 1 || kw["row-mt"] != 1 || kw["tile-columns"] != 1 {
+		t.Fatalf("unexpected tuning args: %v", kw)
+	}
+}
+
+func TestBuildPassKwArgs(t *testing.T) {
+	kw := ffmpeg.KwArgs{"c:v": "libvpx-vp9", "f": "webm"}
+	first := buildPassKwArgs(kw, 1, "/tmp/a.pass")
+	if first["pass"] != 1 || first["f"] != "null" || first["passlogfile"] != "/tmp/a.pass" {
+		t.Fatalf("unexpected first pass args: %v", first)
+	}
+	second := buildPassKwArgs(kw, 2, "/tmp/a.pass")
+	if second["pass"] != 2 || second["f"] != "webm" {
+		t.Fatalf("unexpected second pass args: %v", second)
+	}
+	if _, ok := kw["pass"]; ok {
+		t.Fatalf("base args were modified: %v", kw)
+	}
+}
+
+func TestBuildChromaKeyArg(t *testing.T) {
+	got := buildChromaKeyArg(domain.ChromaKey{Color: "0x00FF00", Similarity: 0.15, Blend: 0.05})
+	if got != "0x00FF00:0.15:0.05" {
+		t.Fatalf("unexpected chromakey: %s", got)
+	}
+}
+
+func TestBuildSetptsArg(t *testing.T) {
+	if got := buildSetptsArg(2); got != "PTS/2" {
+		t.Fatalf("unexpected setpts: %s", got)
+	}
+	if got := buildSetptsArg(1.5); got != "PTS/1.5" {
+		t.Fatalf("unexpected setpts: %s", got)
+	}
+}
+
+func TestBuildImageOutputKwArgs(t *testing.T) {
+	png := buildImageOutputKwArgs(domain.ImageEncodeOptions{Profile: domain.TelegramStaticSticker})
+	if png["vcodec"] != "png" || png["f"] != "image2" {
+		t.Fatalf("unexpected png args: %v", png)
+	}
+	if _, ok := png["quality"]; ok {
+		t.Fatalf("unexpected quality: %v", png)
+	}
+
+	webp := buildImageOutputKwArgs(domain.ImageEncodeOptions{Profile: domain.TelegramThumbnailWebP, Quality: 75})
+	if webp["vcodec"] != "libwebp" || webp["f"] != "webp" || webp["quality"] != 75 {
+		t.Fatalf("unexpected webp args: %v", webp)
+	}
+}
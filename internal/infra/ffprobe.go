@@ -1,9 +1,14 @@
 package infra
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -23,6 +28,10 @@ type probeJSON struct {
 		FrameRate string `json:"r_frame_rate"`
 		CodecName string `json:"codec_name"`
 		Duration  string `json:"duration"`
+		PixFmt    string `json:"pix_fmt"`
+		Tags      struct {
+			AlphaMode string `json:"alpha_mode"`
+		} `json:"tags"`
 	} `json:"streams"`
 	Format struct {
 		FormatName string `json:"format_name"`
@@ -38,7 +47,7 @@ func (r FFprobeRunner) Probe(ctx context.Context, path string) (domain.MediaInfo
 	}
 	args := []string{
 		"-v", "error",
-		"-show_entries", "stream=codec_type,width,height,r_frame_rate,codec_name,duration",
+		"-show_entries", "stream=codec_type,width,height,r_frame_rate,codec_name,duration,pix_fmt:stream_tags=alpha_mode",
 		"-show_entries", "format=duration,format_name",
 		"-of", "json",
 		path,
@@ -47,7 +56,48 @@ func (r FFprobeRunner) Probe(ctx context.Context, path string) (domain.MediaInfo
 	if err != nil {
 		return domain.MediaInfo{}, fmt.Errorf("ffprobe failed: %w", err)
 	}
-	return parseProbeJSON(out)
+	info, err := parseProbeJSON(out)
+	if err != nil {
+		return domain.MediaInfo{}, err
+	}
+	if info.PixelFormat == "pal8" && !info.HasAlpha {
+		info.HasAlpha = paletteHasAlpha(path)
+	}
+	return info, nil
+}
+
+func paletteHasAlpha(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return false
+		}
+		for _, frame := range anim.Image {
+			if hasTransparentEntry(frame.Palette) {
+				return true
+			}
+		}
+		return false
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	palette, ok := cfg.ColorModel.(color.Palette)
+	return ok && hasTransparentEntry(palette)
+}
+
+func hasTransparentEntry(palette color.Palette) bool {
+	for _, c := range palette {
+		if _, _, _, a := c.RGBA(); a < 0xffff {
+			return true
+		}
+	}
+	return false
 }
 
 func parseProbeJSON(data []byte) (domain.MediaInfo, error) {
@@ -66,6 +116,8 @@ func parseProbeJSON(data []byte) (domain.MediaInfo, error) {
 			info.Height = s.Height
 			info.FPS = parseFrameRate(s.FrameRate)
 			info.CodecName = s.CodecName
+			info.PixelFormat = s.PixFmt
+			info.HasAlpha = domain.PixelFormatHasAlpha(s.PixFmt) || s.Tags.AlphaMode == "1"
 			if info.DurationSeconds == 0 {
 				info.DurationSeconds = parseDuration(s.Duration)
 			}
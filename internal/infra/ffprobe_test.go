@@ -1,6 +1,15 @@
 package infra
 
-import "testing"
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestParseProbe(t *testing.T) {
 	jsonStr := `{"streams":[{"codec_type":"video","width":512,"height":256,"r_frame_rate":"30/1","codec_name":"vp9"},{"codec_type":"audio"}],"format":{"format_name":"webm","duration":"2.9","bit_rate":"1234567"}}`
@@ -32,3 +41,52 @@ func TestParseFrameRate(t *testing.T) {
 		t.Fatalf("unexpected fps: %v", fps)
 	}
 }
+
+func TestParseProbeAlpha(t *testing.T) {
+	cases := map[string]bool{
+		`{"streams":[{"codec_type":"video","pix_fmt":"yuv420p"}]}`:                           false,
+		`{"streams":[{"codec_type":"video","pix_fmt":"argb"}]}`:                              true,
+		`{"streams":[{"codec_type":"video","pix_fmt":"yuv420p","tags":{"alpha_mode":"1"}}]}`: true,
+	}
+	for jsonStr, want := range cases {
+		info, err := parseProbeJSON([]byte(jsonStr))
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if info.HasAlpha != want {
+			t.Fatalf("unexpected alpha for %s: %v", jsonStr, info.HasAlpha)
+		}
+	}
+}
+
+func TestPaletteHasAlpha(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, encode func(io.Writer, *image.Paletted) error, palette color.Palette) string {
+		path := filepath.Join(dir, name)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		defer file.Close()
+		if err := encode(file, image.NewPaletted(image.Rect(0, 0, 4, 4), palette)); err != nil {
+			t.Fatalf("encode %s: %v", name, err)
+		}
+		return path
+	}
+	encodeGIF := func(w io.Writer, img *image.Paletted) error { return gif.Encode(w, img, nil) }
+	encodePNG := func(w io.Writer, img *image.Paletted) error { return png.Encode(w, img) }
+	opaque := color.Palette{color.Black, color.White}
+	transparent := color.Palette{color.Transparent, color.White}
+
+	cases := map[string]bool{
+		write("opaque.gif", encodeGIF, opaque):           false,
+		write("transparent.gif", encodeGIF, transparent): true,
+		write("opaque.png", encodePNG, opaque):           false,
+		write("transparent.png", encodePNG, transparent): true,
+	}
+	for path, want := range cases {
+		if got := paletteHasAlpha(path); got != want {
+			t.Fatalf("%s: got %v want %v", filepath.Base(path), got, want)
+		}
+	}
+}
@@ -1,21 +1,137 @@
 package infra
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
+	"strings"
 )
 
-func ListFiles(root string) ([]string, error) {
-	files := make([]string, 0)
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+type ScanOptions struct {
+	Include       []string
+	Exclude       []string
+	MaxDepth      int
+	IncludeHidden bool
+}
+
+type SkippedPath struct {
+	Path   string
+	Reason string
+}
+
+type ScanResult struct {
+	Files   []string
+	Skipped []SkippedPath
+}
+
+func ScanFiles(root string, opts ScanOptions) (ScanResult, error) {
+	ignore, err := loadIgnoreFile(filepath.Join(root, IgnoreFileName))
+	if err != nil {
+		return ScanResult{}, err
+	}
+	return scanFS(os.DirFS(root), root, opts, ignore)
+}
+
+func scanFS(fsys fs.FS, root string, opts ScanOptions, ignore IgnoreMatcher) (ScanResult, error) {
+	result := ScanResult{Files: make([]string, 0)}
+	err := fs.WalkDir(fsys, ".", func(rel string, d fs.DirEntry, err error) error {
+		path := filepath.Join(root, filepath.FromSlash(rel))
 		if err != nil {
-			return err
+			if rel == "." || d == nil {
+				return err
+			}
+			result.Skipped = append(result.Skipped, SkippedPath{Path: path, Reason: err.Error()})
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if rel == "." {
+			return nil
+		}
+		if !d.IsDir() && (rel == IgnoreFileName || strings.HasSuffix(rel, SidecarSuffix)) {
+			return nil
+		}
+		depth := strings.Count(rel, "/") + 1
+
+		reason := skipReason(rel, d, depth, opts, ignore)
+		if reason != "" {
+			result.Skipped = append(result.Skipped, SkippedPath{Path: path, Reason: reason})
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
 		}
 		if d.IsDir() {
 			return nil
 		}
-		files = append(files, path)
+		result.Files = append(result.Files, path)
 		return nil
 	})
-	return files, err
+	return result, err
+}
+
+func skipReason(rel string, d fs.DirEntry, depth int, opts ScanOptions, ignore IgnoreMatcher) string {
+	if !opts.IncludeHidden && strings.HasPrefix(d.Name(), ".") {
+		return "hidden"
+	}
+	for _, pattern := range opts.Exclude {
+		if matchPattern(pattern, rel) {
+			return fmt.Sprintf("excluded by %s", pattern)
+		}
+	}
+	if ignore.Match(rel, d.IsDir()) {
+		return "ignored by " + IgnoreFileName
+	}
+	if d.IsDir() {
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			return fmt.Sprintf("max depth %d reached", opts.MaxDepth)
+		}
+		return ""
+	}
+	if len(opts.Include) > 0 && !matchAny(opts.Include, rel) {
+		return "not matched by include patterns"
+	}
+	return ""
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func ReadFileHeader(path string, size int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if stat, err := file.Stat(); err == nil && stat.Size() < int64(size) {
+		size = int(stat.Size())
+	}
+	header := make([]byte, size)
+	n, err := io.ReadFull(file, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+func loadIgnoreFile(path string) (IgnoreMatcher, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return IgnoreMatcher{}, nil
+	}
+	if err != nil {
+		return IgnoreMatcher{}, err
+	}
+	return ParseIgnore(string(content)), nil
 }
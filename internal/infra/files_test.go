@@ -0,0 +1,148 @@
+package infra
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanFiles(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"a.mp4",
+		"a.mp4~",
+		".hidden.gif",
+		".git/config",
+		"cats/b.gif",
+		"cats/deep/c.gif",
+		"drafts/d.mp4",
+		"raw/e.mov",
+	}
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, IgnoreFileName), []byte("# drafts\ndrafts/\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	result, err := ScanFiles(root, ScanOptions{
+		Exclude:  []string{"*~", "raw/**"},
+		MaxDepth: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := []string{filepath.Join(root, "a.mp4"), filepath.Join(root, "cats", "b.gif")}
+	if len(result.Files) != len(want) {
+		t.Fatalf("unexpected files: %v", result.Files)
+	}
+	for i := range want {
+		if result.Files[i] != want[i] {
+			t.Fatalf("unexpected files: %v", result.Files)
+		}
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range result.Skipped {
+		reasons[s.Path] = s.Reason
+	}
+	wantReasons := map[string]string{
+		filepath.Join(root, ".git"):         "hidden",
+		filepath.Join(root, ".hidden.gif"):  "hidden",
+		filepath.Join(root, "a.mp4~"):       "excluded by *~",
+		filepath.Join(root, "cats", "deep"): "max depth 2 reached",
+		filepath.Join(root, "drafts"):       "ignored by .rttsignore",
+		filepath.Join(root, "raw"):          "excluded by raw/**",
+	}
+	for path, reason := range wantReasons {
+		if reasons[path] != reason {
+			t.Fatalf("%s: reason=%q want %q", path, reasons[path], reason)
+		}
+	}
+}
+
+func TestScanFilesInclude(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.mp4", ".b.gif", "c.png"} {
+		if err := os.WriteFile(filepath.Join(root, f), []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	result, err := ScanFiles(root, ScanOptions{Include: []string{"*.gif", "*.mp4"}, IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("unexpected files: %v", result.Files)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Reason != "not matched by include patterns" {
+		t.Fatalf("unexpected skipped: %v", result.Skipped)
+	}
+}
+
+type unreadableDirFS struct {
+	fs.FS
+	dir string
+}
+
+func (f unreadableDirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.dir {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return fs.ReadDir(f.FS, name)
+}
+
+func TestScanFilesSkipsUnreadableDir(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.mp4", "locked/b.gif", "open/c.gif"} {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	result, err := scanFS(unreadableDirFS{FS: os.DirFS(root), dir: "locked"}, root, ScanOptions{}, IgnoreMatcher{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("expected the readable files, got %v", result.Files)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != filepath.Join(root, "locked") {
+		t.Fatalf("expected the unreadable dir to be skipped, got %+v", result.Skipped)
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := ParseIgnore("*.tmp\n!keep.tmp\n/build\nassets/**/old\n")
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.tmp", false, true},
+		{"sub/a.tmp", false, true},
+		{"keep.tmp", false, false},
+		{"build", true, true},
+		{"sub/build", true, false},
+		{"assets/x/y/old", true, true},
+		{"a.mp4", false, false},
+	}
+	for _, c := range cases {
+		if got := m.Match(c.rel, c.isDir); got != c.want {
+			t.Fatalf("%s: got=%v want=%v", c.rel, got, c.want)
+		}
+	}
+}
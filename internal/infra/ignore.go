@@ -0,0 +1,90 @@
+package infra
+
+import (
+	"path"
+	"strings"
+)
+
+const IgnoreFileName = ".rttsignore"
+
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+func ParseIgnore(content string) IgnoreMatcher {
+	rules := make([]ignoreRule, 0)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " ")
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return IgnoreMatcher{rules: rules}
+}
+
+func (m IgnoreMatcher) Match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchPattern(rule.pattern, rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func matchPattern(pattern string, rel string) bool {
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	if !strings.Contains(pattern, "/") {
+		segments = append([]string{"**"}, segments...)
+	}
+	return matchSegments(segments, parts)
+}
+
+func matchSegments(pattern []string, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], parts[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
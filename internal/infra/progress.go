@@ -0,0 +1,50 @@
+package infra
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type progressWriter struct {
+	buf      []byte
+	current  domain.EncodeProgress
+	onUpdate func(domain.EncodeProgress)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.handleLine(strings.TrimSpace(string(w.buf[:i])))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) handleLine(line string) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return
+	}
+	switch key {
+	case "out_time_us", "out_time_ms":
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+			w.current.OutSeconds = float64(us) / 1e6
+		}
+	case "total_size":
+		if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+			w.current.SizeBytes = size
+		}
+	case "progress":
+		w.current.Done = value == "end"
+		if w.onUpdate != nil {
+			w.onUpdate(w.current)
+		}
+	}
+}
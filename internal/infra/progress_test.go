@@ -0,0 +1,38 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestProgressWriter(t *testing.T) {
+	var updates []domain.EncodeProgress
+	w := &progressWriter{onUpdate: func(p domain.EncodeProgress) {
+		updates = append(updates, p)
+	}}
+	chunks := []string{
+		"frame=10\nout_time_us=400000\nout_time_ms=400000\ntotal_si",
+		"ze=20480\nprogress=continue\n",
+		"out_time_us=N/A\ntotal_size=N/A\nprogress=continue\n",
+		"out_time_us=3000000\ntotal_size=150000\nprogress=end\n",
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	want := []domain.EncodeProgress{
+		{OutSeconds: 0.4, SizeBytes: 20480},
+		{OutSeconds: 0.4, SizeBytes: 20480},
+		{OutSeconds: 3, SizeBytes: 150000, Done: true},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("unexpected updates: %+v", updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Fatalf("update %d: got %+v want %+v", i, updates[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+var (
+	ssimPattern = regexp.MustCompile(`SSIM .*All:([0-9.]+|inf)`)
+	psnrPattern = regexp.MustCompile(`PSNR .*average:([0-9.]+|inf)`)
+)
+
+type QualityMeter struct {
+	Path string
+}
+
+func (m QualityMeter) Measure(ctx context.Context, sourcePath string, outputPath string, ref domain.QualityReference, metric domain.QualityMetric) (float64, error) {
+	bin := m.Path
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	args := []string{"-hide_banner", "-nostats", "-i", outputPath}
+	if ref.Window.StartSeconds > 0 {
+		args = append(args, "-ss", formatSeconds(ref.Window.StartSeconds))
+	}
+	if ref.Window.EndSeconds > 0 {
+		args = append(args, "-t", formatSeconds(ref.Window.EndSeconds-ref.Window.StartSeconds))
+	}
+	args = append(args,
+		"-i", sourcePath,
+		"-filter_complex", buildQualityGraph(ref, metric),
+		"-f", "null", "-",
+	)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("quality measurement failed: %w%s", err, formatFFmpegStderr(stderr.String()))
+	}
+	return parseQualityScore(stderr.String(), metric)
+}
+
+func buildQualityGraph(ref domain.QualityReference, metric domain.QualityMetric) string {
+	size := fmt.Sprintf("%d:%d", ref.Width, ref.Height)
+	mainChain := make([]string, 0, 4)
+	if ref.PadSide == 0 {
+		mainChain = append(mainChain, "scale="+size)
+	} else if ref.CandidateWidth > 0 && (ref.CandidateWidth != ref.Width || ref.CandidateHeight != ref.Height) {
+		mainChain = append(mainChain,
+			fmt.Sprintf("crop=%d:%d:(iw-ow)/2:(ih-oh)/2", ref.CandidateWidth, ref.CandidateHeight),
+			"scale="+size,
+			"pad="+buildPadArg(ref.PadSide),
+		)
+	}
+	mainChain = append(mainChain, "setpts=PTS-STARTPTS")
+
+	refChain := make([]string, 0, 5)
+	if !ref.Crop.IsZero() {
+		refChain = append(refChain, "crop="+ref.Crop.String())
+	}
+	if ref.Speed > 0 && ref.Speed != 1 {
+		refChain = append(refChain, "setpts="+buildSetptsArg(ref.Speed))
+	}
+	refChain = append(refChain, "scale="+size)
+	if ref.PadSide > 0 {
+		refChain = append(refChain, "pad="+buildPadArg(ref.PadSide))
+	}
+	refChain = append(refChain, "setpts=PTS-STARTPTS")
+
+	return fmt.Sprintf("[0:v]%s[main];[1:v]%s[ref];[main][ref]%s=shortest=1",
+		strings.Join(mainChain, ","), strings.Join(refChain, ","), metric)
+}
+
+func parseQualityScore(output string, metric domain.QualityMetric) (float64, error) {
+	pattern := ssimPattern
+	if metric == domain.QualityPSNR {
+		pattern = psnrPattern
+	}
+	matches := pattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("no %s score in ffmpeg output", metric)
+	}
+	value := matches[len(matches)-1][1]
+	if value == "inf" {
+		return math.Inf(1), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,52 @@
+package infra
+
+import (
+	"math"
+	"testing"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+func TestParseQualityScore(t *testing.T) {
+	ssim := "[Parsed_ssim_4 @ 0x1] SSIM Y:0.981 (17.2) U:0.990 (20.0) V:0.991 (20.4) All:0.9853 (18.3)\n"
+	got, err := parseQualityScore(ssim, domain.QualitySSIM)
+	if err != nil || got != 0.9853 {
+		t.Fatalf("unexpected ssim: %v err=%v", got, err)
+	}
+
+	psnr := "[Parsed_psnr_4 @ 0x1] PSNR y:36.12 u:41.00 v:41.50 average:37.48 min:33.10 max:40.20\n"
+	got, err = parseQualityScore(psnr, domain.QualityPSNR)
+	if err != nil || got != 37.48 {
+		t.Fatalf("unexpected psnr: %v err=%v", got, err)
+	}
+
+	got, err = parseQualityScore("PSNR y:inf u:inf v:inf average:inf min:inf max:inf", domain.QualityPSNR)
+	if err != nil || !math.IsInf(got, 1) {
+		t.Fatalf("expected infinite psnr, got %v err=%v", got, err)
+	}
+
+	if _, err := parseQualityScore("nothing here", domain.QualitySSIM); err == nil {
+		t.Fatalf("expected error without score")
+	}
+}
+
+func TestBuildQualityGraph(t *testing.T) {
+	ref := domain.QualityReference{Width: 512, Height: 288, Speed: 2, Crop: domain.CropRect{Width: 1280, Height: 720}}
+	got := buildQualityGraph(ref, domain.QualitySSIM)
+	want := "[0:v]scale=512:288,setpts=PTS-STARTPTS[main];[1:v]crop=1280:720:0:0,setpts=PTS/2,scale=512:288,setpts=PTS-STARTPTS[ref];[main][ref]ssim=shortest=1"
+	if got != want {
+		t.Fatalf("unexpected graph:\n%s\nwant\n%s", got, want)
+	}
+
+	square := buildQualityGraph(domain.QualityReference{Width: 100, Height: 56, PadSide: 100}, domain.QualityPSNR)
+	wantSquare := "[0:v]setpts=PTS-STARTPTS[main];[1:v]scale=100:56,pad=100:100:(ow-iw)/2:(oh-ih)/2:color=0x00000000,setpts=PTS-STARTPTS[ref];[main][ref]psnr=shortest=1"
+	if square != wantSquare {
+		t.Fatalf("unexpected square graph:\n%s", square)
+	}
+
+	smaller := buildQualityGraph(domain.QualityReference{Width: 100, Height: 56, PadSide: 100, CandidateWidth: 80, CandidateHeight: 44}, domain.QualitySSIM)
+	wantSmaller := "[0:v]crop=80:44:(iw-ow)/2:(ih-oh)/2,scale=100:56,pad=100:100:(ow-iw)/2:(oh-ih)/2:color=0x00000000,setpts=PTS-STARTPTS[main];[1:v]scale=100:56,pad=100:100:(ow-iw)/2:(oh-ih)/2:color=0x00000000,setpts=PTS-STARTPTS[ref];[main][ref]ssim=shortest=1"
+	if smaller != wantSmaller {
+		t.Fatalf("unexpected upscaled candidate graph:\n%s", smaller)
+	}
+}
@@ -0,0 +1,73 @@
+package infra
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+type SceneAnalyzer struct {
+	Path string
+}
+
+func (a SceneAnalyzer) SceneScores(ctx context.Context, path string) ([]domain.FrameScore, error) {
+	bin := a.Path
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	args := []string{
+		"-hide_banner", "-nostats",
+		"-i", path,
+		"-an",
+		"-vf", "scale=160:-2,select='gte(scene,0)',metadata=print:file=-",
+		"-f", "null", "-",
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("scene analysis failed: %w%s", err, formatFFmpegStderr(stderr.String()))
+	}
+	return parseSceneScores(out)
+}
+
+func parseSceneScores(data []byte) ([]domain.FrameScore, error) {
+	scores := make([]domain.FrameScore, 0)
+	current := -1.0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "frame:") {
+			current = -1
+			for _, field := range strings.Fields(line) {
+				value, ok := strings.CutPrefix(field, "pts_time:")
+				if !ok {
+					continue
+				}
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pts_time: %s", value)
+				}
+				current = parsed
+			}
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "lavfi.scene_score=")
+		if !ok || current < 0 {
+			continue
+		}
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scene score: %s", value)
+		}
+		scores = append(scores, domain.FrameScore{TimeSeconds: current, Score: score})
+	}
+	return scores, scanner.Err()
+}
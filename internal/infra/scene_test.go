@@ -0,0 +1,27 @@
+package infra
+
+import "testing"
+
+func TestParseSceneScores(t *testing.T) {
+	data := []byte(`frame:0    pts:0       pts_time:0
+lavfi.scene_score=0.000000
+frame:1    pts:512     pts_time:0.04
+lavfi.scene_score=0.125000
+frame:2    pts:1024    pts_time:0.08
+lavfi.scene_score=0.731000
+`)
+	scores, err := parseSceneScores(data)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(scores) != 3 {
+		t.Fatalf("unexpected scores: %+v", scores)
+	}
+	if scores[2].TimeSeconds != 0.08 || scores[2].Score != 0.731 {
+		t.Fatalf("unexpected last score: %+v", scores[2])
+	}
+
+	if _, err := parseSceneScores([]byte("frame:0 pts_time:x\n")); err == nil {
+		t.Fatalf("expected error for invalid pts_time")
+	}
+}
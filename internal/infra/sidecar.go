@@ -0,0 +1,65 @@
+package infra
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/freesiapro/resize-to-telegram-sticker/internal/domain"
+)
+
+const SidecarSuffix = ".rtts.json"
+
+type sidecarJSON struct {
+	Start sidecarTime `json:"start"`
+	End   sidecarTime `json:"end"`
+}
+
+type sidecarTime float64
+
+func (t *sidecarTime) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*t = sidecarTime(seconds)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expected seconds or a timestamp, got %s", data)
+	}
+	parsed, err := domain.ParseTimestamp(text)
+	if err != nil {
+		return err
+	}
+	*t = sidecarTime(parsed)
+	return nil
+}
+
+func SidecarPath(inputPath string) string {
+	return inputPath + SidecarSuffix
+}
+
+func ReadSidecar(inputPath string) (domain.TrimWindow, bool, error) {
+	data, err := os.ReadFile(SidecarPath(inputPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return domain.TrimWindow{}, false, nil
+	}
+	if err != nil {
+		return domain.TrimWindow{}, false, err
+	}
+	return parseSidecar(data)
+}
+
+func parseSidecar(data []byte) (domain.TrimWindow, bool, error) {
+	var s sidecarJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return domain.TrimWindow{}, false, fmt.Errorf("invalid sidecar: %w", err)
+	}
+	window := domain.TrimWindow{StartSeconds: float64(s.Start), EndSeconds: float64(s.End)}
+	if err := window.Validate(); err != nil {
+		return domain.TrimWindow{}, false, fmt.Errorf("invalid sidecar: %w", err)
+	}
+	return window, true, nil
+}
@@ -0,0 +1,39 @@
+package infra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSidecar(t *testing.T) {
+	window, ok, err := parseSidecar([]byte(`{"start": "0:01.5", "end": 4}`))
+	if err != nil || !ok {
+		t.Fatalf("unexpected result: ok=%v err=%v", ok, err)
+	}
+	if window.StartSeconds != 1.5 || window.EndSeconds != 4 {
+		t.Fatalf("unexpected window: %+v", window)
+	}
+
+	for _, data := range []string{`{"start": "soon"}`, `{"start": 5, "end": 2}`, `[`} {
+		if _, _, err := parseSidecar([]byte(data)); err == nil {
+			t.Fatalf("expected error for %s", data)
+		}
+	}
+}
+
+func TestReadSidecar(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "clip.mp4")
+	if _, ok, err := ReadSidecar(input); ok || err != nil {
+		t.Fatalf("expected missing sidecar, got ok=%v err=%v", ok, err)
+	}
+
+	if err := os.WriteFile(SidecarPath(input), []byte(`{"start": 2}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	window, ok, err := ReadSidecar(input)
+	if err != nil || !ok || window.StartSeconds != 2 {
+		t.Fatalf("unexpected sidecar: %+v ok=%v err=%v", window, ok, err)
+	}
+}